@@ -0,0 +1,175 @@
+package timepolicy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1 << 50,
+	"pb":  1e15,
+	"pib": 1 << 50,
+}
+
+// ParseByteSize parses a number of bytes with an optional unit, such as 500GiB,
+// 1.5TB, or 512M. Units of KB, MB, GB, TB, and PB are decimal; units of KiB,
+// MiB, GiB, TiB, PiB, and single letters (as written by du -h) are binary.
+func ParseByteSize(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	numberEnd := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+
+	if numberEnd == -1 {
+		numberEnd = len(trimmed)
+	}
+
+	multiplier, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(trimmed[numberEnd:]))]
+	if !ok {
+		return 0, fmt.Errorf("parsing unit: %s", trimmed[numberEnd:])
+	}
+
+	number, err := strconv.ParseFloat(trimmed[0:numberEnd], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing number: %v", err)
+	}
+
+	size := math.Ceil(number * multiplier)
+	if size >= math.MaxInt64 {
+		// float64(math.MaxInt64) rounds up to 2^63, which int64 cannot hold
+		return 0, errors.New("size is too large")
+	}
+
+	return int64(size), nil
+}
+
+// EntrySizeFunc returns the size of an entry, such as in bytes.
+type EntrySizeFunc func(e *Entry) (int64, error)
+
+// NewFieldEntrySizeFunc returns the size parsed from a field of each entry,
+// starting at 0. See ParseByteSize.
+func NewFieldEntrySizeFunc(fieldIdx int) EntrySizeFunc {
+	return func(e *Entry) (int64, error) {
+		if fieldIdx >= len(e.Fields) {
+			return 0, fmt.Errorf("field %d: not found", fieldIdx+1)
+		}
+
+		size, err := ParseByteSize(e.Fields[fieldIdx])
+		if err != nil {
+			return 0, fmt.Errorf("field %d: %v", fieldIdx+1, err)
+		}
+
+		return size, nil
+	}
+}
+
+// EnforceBudget evicts selected entries until their total size is at most
+// budget and returns the total. Entries selected only by later policies are
+// evicted first, oldest first. Pinned entries and the keep newest selected
+// entries are never evicted, so the total may still exceed budget. It should be
+// called after Flush.
+func (p *PolicySelectionSet) EnforceBudget(budget int64, keep int, size EntrySizeFunc) (int64, error) {
+	var total int64
+
+	entries := p.Entries()
+	sizes := map[*Entry]int64{}
+
+	for _, e := range entries {
+		entrySize, err := size(e)
+		if err != nil {
+			return 0, fmt.Errorf("sizing entry %s: %v", e.Raw, err)
+		}
+
+		if total > math.MaxInt64-entrySize {
+			return 0, fmt.Errorf("sizing entry %s: total exceeds %d", e.Raw, int64(math.MaxInt64))
+		}
+
+		sizes[e] = entrySize
+		total += entrySize
+	}
+
+	if total <= budget {
+		return total, nil
+	}
+
+	var candidates []*Entry
+
+	for _, e := range entries {
+		if !p.IsPinned(e) {
+			candidates = append(candidates, e)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Time.After(candidates[j].Time)
+	})
+
+	if keep >= len(candidates) {
+		return total, nil
+	}
+
+	candidates = candidates[keep:]
+
+	policyIdxs := map[*PolicySelection]int{}
+
+	for policyIdx, policySelection := range p.specs {
+		policyIdxs[policySelection] = policyIdx
+	}
+
+	// the earliest policy claiming an entry, including by fill
+	priorities := map[*Entry]int{}
+
+	for _, e := range candidates {
+		for claimIdx, policySelection := range p.claims[e] {
+			if policyIdx := policyIdxs[policySelection]; claimIdx == 0 || policyIdx < priorities[e] {
+				priorities[e] = policyIdx
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if priorities[candidates[i]] != priorities[candidates[j]] {
+			return priorities[candidates[i]] > priorities[candidates[j]]
+		}
+
+		return candidates[i].Time.Before(candidates[j].Time)
+	})
+
+	for _, e := range candidates {
+		if total <= budget {
+			break
+		}
+
+		for _, policySelection := range p.claims[e] {
+			policySelection.remove(e)
+		}
+
+		delete(p.claims, e)
+		total -= sizes[e]
+
+		err := p.evictions.WriteEntry(e)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return total, nil
+}
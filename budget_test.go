@@ -0,0 +1,178 @@
+package timepolicy
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected int64
+	}{
+		{"1024", 1024},
+		{"500GiB", 500 << 30},
+		{"1.5TB", 1500000000000},
+		{"512M", 512 << 20},
+		{"2 kb", 2000},
+		{"10B", 10},
+	} {
+		actual, err := ParseByteSize(tc.value)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		} else if _e, _a := tc.expected, actual; _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+
+	for _, value := range []string{"", "GiB", "10XB", "1.2.3K", "99999999P", "8192PiB", "9223372036854775808"} {
+		if _, err := ParseByteSize(value); err == nil {
+			t.Fatalf("expected error for %s but got: %v", value, err)
+		}
+	}
+}
+
+func TestSetEnforceBudget(t *testing.T) {
+	Now = stubNow
+
+	daily, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	monthly, err := ParsePolicySpecString("monthly", "1y;by=month")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	evictions := bytes.NewBuffer(nil)
+	pss := NewPolicySelectionSet([]*PolicySpec{daily, monthly}, NewEntryWriter(evictions))
+	pss.Pin(func(e *Entry) (bool, error) {
+		return e.Fields[2] == "held", nil
+	})
+
+	for _, raw := range []string{
+		"2022-09-30T12:00:00Z 400 held",
+		"2022-10-31T12:00:00Z 300 ok",
+		"2022-11-30T12:00:00Z 300 ok",
+		"2022-12-29T12:00:00Z 200 ok",
+		"2022-12-30T12:00:00Z 200 ok",
+		"2022-12-31T12:00:00Z 200 ok",
+	} {
+		fields := strings.Fields(raw)
+
+		if _, err := pss.EvaluateEntry(&Entry{Raw: raw, Fields: fields, Time: mustParseRFC3339(fields[0])}); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if err := pss.Flush(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	total, err := pss.EnforceBudget(1100, 1, NewFieldEntrySizeFunc(1))
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := int64(1000), total; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "2022-10-31T12:00:00Z 300 ok\n2022-11-30T12:00:00Z 300 ok\n", evictions.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	// daily entries are evicted oldest first, except for the newest
+	total, err = pss.EnforceBudget(0, 1, NewFieldEntrySizeFunc(1))
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := int64(600), total; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	var actual []string
+
+	for _, e := range pss.Entries() {
+		actual = append(actual, e.Fields[0])
+	}
+
+	sort.Strings(actual)
+
+	if _e, _a := "2022-09-30T12:00:00Z,2022-12-31T12:00:00Z", strings.Join(actual, ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 1, len(pss.Selections()[1].Entries()); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	if _, err := pss.EnforceBudget(0, 0, NewFieldEntrySizeFunc(5)); err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
+
+func TestSetEnforceBudgetFill(t *testing.T) {
+	Now = stubNow
+
+	monthly, err := ParsePolicySpecString("monthly", "by=month")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	daily, err := ParsePolicySpecString("daily", "by=day;fill")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	evictions := bytes.NewBuffer(nil)
+	pss := NewPolicySelectionSet([]*PolicySpec{monthly, daily}, NewEntryWriter(evictions))
+
+	for _, raw := range []string{
+		"2022-12-31T18:00:00Z 10",
+		"2022-12-30T18:00:00Z 10",
+		"2022-12-30T06:00:00Z 10",
+	} {
+		fields := strings.Fields(raw)
+
+		if _, err := pss.EvaluateEntry(&Entry{Raw: raw, Fields: fields, Time: mustParseRFC3339(fields[0])}); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if err := pss.Flush(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	// the entry kept by fill belongs to daily, so it is evicted first as the oldest
+	total, err := pss.EnforceBudget(20, 1, NewFieldEntrySizeFunc(1))
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := int64(20), total; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "2022-12-30T06:00:00Z 10\n", evictions.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
+
+func TestSetEnforceBudgetOverflow(t *testing.T) {
+	Now = stubNow
+
+	spec, err := ParsePolicySpecString("all", "by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	pss := NewPolicySelectionSet([]*PolicySpec{spec}, NewDiscardEntryWriter())
+
+	for _, raw := range []string{
+		"2022-12-31T18:00:00Z 8191PiB",
+		"2022-12-30T18:00:00Z 8191PiB",
+	} {
+		fields := strings.Fields(raw)
+
+		if _, err := pss.EvaluateEntry(&Entry{Raw: raw, Fields: fields, Time: mustParseRFC3339(fields[0])}); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if _, err := pss.EnforceBudget(1, 0, NewFieldEntrySizeFunc(1)); err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package checkcmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/rootcmd"
+)
+
+var ErrStale error = cmdutil.NewErrorWithExitCode(errors.New("newest entry is too old"), 3)
+var ErrMissingBuckets error = cmdutil.NewErrorWithExitCode(errors.New("expected buckets are missing"), 4)
+var ErrStaleAndMissingBuckets error = cmdutil.NewErrorWithExitCode(errors.New("newest entry is too old and expected buckets are missing"), 5)
+
+type Command struct {
+	rootcmd.InputOptions `embed:""`
+
+	NewestWithin rootcmd.DurationValue `name:"newest-within" placeholder:"DURATION" help:"Fail when the newest entry is older than the duration (e.g. 26h)."`
+	Expect       []string              `name:"expect" sep:"none" placeholder:"STRING..." help:"One or more policies whose complete buckets must each have an entry (e.g. 7d;by=day). Policies must use a range and a simple by value."`
+}
+
+func (cmd *Command) BeforeApply() error {
+	cmd.InputOptions.SetDefaults()
+
+	return nil
+}
+
+func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	var reference = timepolicy.Now()
+	var expects []*timepolicy.PolicySpec
+
+	for expectIdx, expectRaw := range cmd.Expect {
+		spec, err := timepolicy.ParsePolicySpecString(fmt.Sprintf("expect-%d", expectIdx), expectRaw)
+		if err != nil {
+			return fmt.Errorf("parsing expect %d: %v", expectIdx, err)
+		}
+
+		expects = append(expects, spec.Resolve(reference))
+	}
+
+	var entries []*timepolicy.Entry
+	var newest *timepolicy.Entry
+
+	_, err := cmd.ReadEntries(func(offset int, entry *timepolicy.Entry) error {
+		entries = append(entries, entry)
+
+		if newest == nil || entry.Time.After(newest.Time) {
+			newest = entry
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var stale, missing bool
+
+	if cmd.NewestWithin > 0 {
+		if newest == nil {
+			stale = true
+
+			if !appOptions.Quiet {
+				fmt.Fprintf(app.Stdout, "newest: no entries (expected within %s)\n", time.Duration(cmd.NewestWithin))
+			}
+		} else if age := reference.Sub(newest.Time); age > time.Duration(cmd.NewestWithin) {
+			stale = true
+
+			if !appOptions.Quiet {
+				fmt.Fprintf(app.Stdout, "newest: %s is %s old (expected within %s)\n", newest.Time.Format(time.RFC3339), age.Truncate(time.Second), time.Duration(cmd.NewestWithin))
+			}
+		}
+	}
+
+	loc := reference.Location()
+	if newest != nil {
+		loc = newest.Time.Location()
+	}
+
+	for _, expect := range expects {
+		buckets, err := timepolicy.FindMissingBuckets(expect, entries, loc)
+		if err != nil {
+			return fmt.Errorf("checking %s: %v", expect.Name(), err)
+		}
+
+		if len(buckets) > 0 {
+			missing = true
+		}
+
+		if appOptions.Quiet {
+			continue
+		}
+
+		for _, bucket := range buckets {
+			fmt.Fprintf(app.Stdout, "%s: missing %s\n", expect.Name(), bucket)
+		}
+	}
+
+	if stale && missing {
+		return ErrStaleAndMissingBuckets
+	} else if stale {
+		return ErrStale
+	} else if missing {
+		return ErrMissingBuckets
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package diffcmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/rootcmd"
+)
+
+var ErrChanged error = cmdutil.NewErrorWithExitCode(errors.New("entries changed"), 1)
+
+type Command struct {
+	rootcmd.InputOptions `embed:""`
+
+	OldPolicies   rootcmd.PolicyValueList `name:"old-policy" placeholder:"STRING..." help:"One or more policies currently used. See POLICY SPECIFICATIONS."`
+	OldPresets    []string                `name:"old-preset" sep:"none" placeholder:"NAME[:ARGS]..." help:"One or more named sets of policies currently used. See the presets command."`
+	OldPolicyFile string                  `name:"old-policy-file" placeholder:"PATH" type:"existingfile" help:"Read policies currently used from a file."`
+	OldExcludes   []string                `name:"old-exclude" sep:"none" placeholder:"STRING..." help:"One or more exclusions currently used."`
+	OldExclusive  bool                    `name:"old-exclusive" help:"Whether policies currently used are evaluated in exclusive mode."`
+	NewPolicies   rootcmd.PolicyValueList `name:"new-policy" placeholder:"STRING..." help:"One or more proposed policies. See POLICY SPECIFICATIONS."`
+	NewPresets    []string                `name:"new-preset" sep:"none" placeholder:"NAME[:ARGS]..." help:"One or more proposed named sets of policies. See the presets command."`
+	NewPolicyFile string                  `name:"new-policy-file" placeholder:"PATH" type:"existingfile" help:"Read proposed policies from a file."`
+	NewExcludes   []string                `name:"new-exclude" sep:"none" placeholder:"STRING..." help:"One or more proposed exclusions."`
+	NewExclusive  bool                    `name:"new-exclusive" help:"Whether proposed policies are evaluated in exclusive mode."`
+	Unchanged     bool                    `name:"unchanged" help:"Also list entries which are selected by both (prefixed with =)."`
+}
+
+func (cmd *Command) BeforeApply() error {
+	cmd.InputOptions.SetDefaults()
+
+	return nil
+}
+
+func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	var reference = timepolicy.Now()
+
+	oldPolicies, err := rootcmd.LoadPolicySpecs(cmd.OldPolicies, cmd.OldPresets, cmd.OldPolicyFile, reference)
+	if err != nil {
+		return fmt.Errorf("old policies: %v", err)
+	}
+
+	newPolicies, err := rootcmd.LoadPolicySpecs(cmd.NewPolicies, cmd.NewPresets, cmd.NewPolicyFile, reference)
+	if err != nil {
+		return fmt.Errorf("new policies: %v", err)
+	}
+
+	oldSelections := timepolicy.NewPolicySelectionSet(oldPolicies, timepolicy.NewDiscardEntryWriter())
+	oldSelections.SetExclusive(cmd.OldExclusive)
+	newSelections := timepolicy.NewPolicySelectionSet(newPolicies, timepolicy.NewDiscardEntryWriter())
+	newSelections.SetExclusive(cmd.NewExclusive)
+
+	if err := rootcmd.ApplyExcludes(oldSelections, cmd.OldExcludes, reference); err != nil {
+		return fmt.Errorf("old policies: %v", err)
+	} else if err := rootcmd.ApplyExcludes(newSelections, cmd.NewExcludes, reference); err != nil {
+		return fmt.Errorf("new policies: %v", err)
+	}
+
+	var entries []*timepolicy.Entry
+
+	_, err = cmd.ReadEntries(func(offset int, entry *timepolicy.Entry) error {
+		entries = append(entries, entry)
+
+		if _, err := oldSelections.EvaluateEntry(entry); err != nil {
+			return fmt.Errorf("processing entry %d: old policies: %v", offset, err)
+		} else if _, err := newSelections.EvaluateEntry(entry); err != nil {
+			return fmt.Errorf("processing entry %d: new policies: %v", offset, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := oldSelections.Flush(); err != nil {
+		return fmt.Errorf("old policies: %v", err)
+	} else if err := newSelections.Flush(); err != nil {
+		return fmt.Errorf("new policies: %v", err)
+	}
+
+	oldSelected := entrySet(oldSelections.Entries())
+	newSelected := entrySet(newSelections.Entries())
+
+	var newlyEvicted, newlyKept, unchanged int
+
+	for _, entry := range entries {
+		_, oldKept := oldSelected[entry]
+		_, newKept := newSelected[entry]
+
+		var prefix string
+
+		if oldKept && !newKept {
+			newlyEvicted++
+			prefix = "-"
+		} else if !oldKept && newKept {
+			newlyKept++
+			prefix = "+"
+		} else {
+			unchanged++
+
+			if !cmd.Unchanged || !oldKept {
+				continue
+			}
+
+			prefix = "="
+		}
+
+		if !appOptions.Quiet {
+			fmt.Fprintf(app.Stdout, "%s %s\n", prefix, entry.Raw)
+		}
+	}
+
+	if !appOptions.Quiet {
+		fmt.Fprintf(app.Stderr, "entries: newly-evicted=%d newly-kept=%d unchanged=%d\n", newlyEvicted, newlyKept, unchanged)
+	}
+
+	if newlyEvicted > 0 || newlyKept > 0 {
+		return ErrChanged
+	}
+
+	return nil
+}
+
+func entrySet(entries []*timepolicy.Entry) map[*timepolicy.Entry]struct{} {
+	res := map[*timepolicy.Entry]struct{}{}
+
+	for _, entry := range entries {
+		res[entry] = struct{}{}
+	}
+
+	return res
+}
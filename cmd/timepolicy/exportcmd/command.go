@@ -0,0 +1,53 @@
+package exportcmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/rootcmd"
+)
+
+type Command struct {
+	rootcmd.PolicyOptions `embed:""`
+
+	To               string `name:"to" required:"" enum:"borg,restic,sanoid" placeholder:"FORMAT" help:"Format of the retention configuration (borg, restic, sanoid)."`
+	AllowDifferences bool   `name:"allow-differences" help:"Export even when the tool cannot match the behavior of the policies, with a warning for each difference."`
+}
+
+func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	if len(cmd.Excludes) > 0 {
+		return errors.New("exporting: exclusions cannot be exported")
+	} else if cmd.Exclusive {
+		return errors.New("exporting: exclusive mode cannot be exported")
+	}
+
+	specs, err := cmd.PolicySpecs(timepolicy.Now())
+	if err != nil {
+		return err
+	} else if len(specs) == 0 {
+		return errors.New("exporting: no policies configured")
+	}
+
+	exported, differences, err := timepolicy.ExportPolicies(cmd.To, specs)
+	if err != nil {
+		return fmt.Errorf("exporting %s: %v", cmd.To, err)
+	} else if len(differences) > 0 && !cmd.AllowDifferences {
+		return fmt.Errorf("exporting %s: %s cannot match policies: %s (use --allow-differences to export anyway)", cmd.To, cmd.To, strings.Join(differences, "; "))
+	}
+
+	if appOptions.Quiet {
+		return nil
+	}
+
+	for _, difference := range differences {
+		fmt.Fprintf(app.Stderr, "warning: %s\n", difference)
+	}
+
+	fmt.Fprintln(app.Stdout, strings.TrimSuffix(exported, "\n"))
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package importcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+)
+
+type Command struct {
+	From             string   `name:"from" required:"" enum:"borg,restic,sanoid" placeholder:"FORMAT" help:"Format of the retention configuration (borg, restic, sanoid)."`
+	AllowDifferences bool     `name:"allow-differences" help:"Import policies even when they cannot match the behavior of the tool, with a warning for each difference."`
+	Input            []string `arg:"" optional:"" passthrough:"" placeholder:"CONFIG" help:"Keep flags of borg prune or restic forget, or the sanoid configuration. Read from stdin when empty."`
+}
+
+func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	input := strings.Join(cmd.Input, " ")
+
+	if len(cmd.Input) == 0 {
+		buf, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading input: %v", err)
+		}
+
+		input = string(buf)
+	}
+
+	lines, differences, err := timepolicy.ImportPolicies(cmd.From, input)
+	if err != nil {
+		return fmt.Errorf("importing %s: %v", cmd.From, err)
+	} else if len(differences) > 0 && !cmd.AllowDifferences {
+		return fmt.Errorf("importing %s: policies cannot match %s: %s (use --allow-differences to import anyway)", cmd.From, cmd.From, strings.Join(differences, "; "))
+	}
+
+	if appOptions.Quiet {
+		return nil
+	}
+
+	for _, difference := range differences {
+		fmt.Fprintf(app.Stderr, "warning: %s\n", difference)
+		fmt.Fprintf(app.Stdout, "# warning: %s\n", difference)
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(app.Stdout, line)
+	}
+
+	return nil
+}
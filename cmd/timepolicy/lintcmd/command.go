@@ -0,0 +1,79 @@
+package lintcmd
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/rootcmd"
+)
+
+type Command struct {
+	Policies   []string `name:"policy" short:"p" sep:"none" placeholder:"STRING..." help:"One or more policies to check. See POLICY SPECIFICATIONS."`
+	PolicyFile string   `name:"policy-file" placeholder:"PATH" type:"existingfile" help:"Read additional policies from a file, one per line. Empty lines and lines starting with # are ignored."`
+	Strict     bool     `name:"strict" help:"Exit with a non-zero status when there are warnings."`
+}
+
+func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	var specs []*timepolicy.PolicySpec
+	var errorsFound, warningsFound int
+	var names = map[string]struct{}{}
+
+	parse := func(source string, raw string) {
+		name := fmt.Sprintf("policy-%d", len(specs)+errorsFound)
+
+		spec, err := timepolicy.ParsePolicySpecString(name, raw)
+		if err != nil {
+			errorsFound++
+
+			if !appOptions.Quiet {
+				fmt.Fprintf(app.Stdout, "%s: error: %s%v\n", name, source, err)
+			}
+
+			return
+		} else if _, known := names[spec.Name()]; known {
+			errorsFound++
+
+			if !appOptions.Quiet {
+				fmt.Fprintf(app.Stdout, "%s: error: %sname is already used by another policy\n", spec.Name(), source)
+			}
+
+			return
+		}
+
+		names[spec.Name()] = struct{}{}
+		specs = append(specs, spec)
+	}
+
+	for _, raw := range cmd.Policies {
+		parse("", raw)
+	}
+
+	if cmd.PolicyFile != "" {
+		lines, err := rootcmd.ReadPolicyFile(cmd.PolicyFile)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			parse(fmt.Sprintf("line %d: ", line.Number), line.Raw)
+		}
+	}
+
+	for _, issue := range timepolicy.LintPolicySpecs(specs) {
+		warningsFound++
+
+		if !appOptions.Quiet {
+			fmt.Fprintf(app.Stdout, "%s: warning: %s\n", issue.Policy, issue.Message)
+		}
+	}
+
+	if errorsFound > 0 {
+		return cmdutil.NewErrorWithExitCode(fmt.Errorf("found %d error(s) and %d warning(s)", errorsFound, warningsFound), 2)
+	} else if cmd.Strict && warningsFound > 0 {
+		return cmdutil.NewErrorWithExitCode(fmt.Errorf("found %d warning(s)", warningsFound), 1)
+	}
+
+	return nil
+}
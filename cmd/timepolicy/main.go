@@ -6,14 +6,31 @@ import (
 
 	"github.com/alecthomas/kong"
 	"github.com/dpb587/timepolicy/cmd/cmdutil"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/checkcmd"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/diffcmd"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/exportcmd"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/importcmd"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/lintcmd"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/presetscmd"
 	"github.com/dpb587/timepolicy/cmd/timepolicy/rootcmd"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/schedulecmd"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/simulatecmd"
 )
 
 const mainName = "timepolicy"
 
 type mainOptions struct {
 	*cmdutil.AppOptions `group:"Global Flags"`
-	*rootcmd.Command    `cmd:""`
+
+	Select   rootcmd.Command     `cmd:"" default:"withargs" help:"Select entries that match policies (default)."`
+	Lint     lintcmd.Command     `cmd:"" help:"Check policies for errors and likely mistakes without reading entries."`
+	Simulate simulatecmd.Command `cmd:"" help:"Simulate policies against entries generated at a regular interval."`
+	Diff     diffcmd.Command     `cmd:"" help:"Compare which entries are selected by two sets of policies."`
+	Schedule schedulecmd.Command `cmd:"" help:"Project when each selected entry will no longer be selected."`
+	Check    checkcmd.Command    `cmd:"" help:"Check that entries are recent and expected buckets are not missing."`
+	Presets  presetscmd.Command  `cmd:"" help:"List and show named sets of policies."`
+	Import   importcmd.Command   `cmd:"" help:"Translate borg, restic, or sanoid retention into policies."`
+	Export   exportcmd.Command   `cmd:"" help:"Translate policies into borg, restic, or sanoid retention."`
 }
 
 func main() {
@@ -42,14 +59,22 @@ func main() {
 
 Policies evaluate entries based on (1) being within a Time Range and (2) Optional Qualifiers. An entry is selected while one or more policies apply to it.
 
-Time Range should be in the format of {INT}{unit}. Supported units are: s for seconds, h for hours, d for days, m for months, and y for years.
+Time Range should be in the format of {INT}{unit}. Supported units are: s for seconds, h for hours, d for days, w for weeks, m for months, and y for years.
 
 Optional Qualifiers may be zero or more of the following:
 
  - if={EXPR} - an expression that must be true for the entry to be considered (in addition to Time Range). Expressions must evaluate to true or false. See ADVANCED EXPRESSIONS for details.
- - by={EXPR} - a method to further segment matching entries. Simple values of year, month (year-month), day, (year-month-day), and hour (year-month-day-hour) are supported; and ADVANCED EXPRESSIONS may be used for complex strategies.
+ - by={EXPR} - a method to further segment matching entries. Simple values of year, month (year-month), week (ISO year-week, starting Monday), day, (year-month-day), and hour (year-month-day-hour) are supported; simple values may be offset from the calendar with @, such as day@04:00 (days end at 04:00), hour@:30, week@sun, month@15, or year@04-01, optionally followed by a time for week, month, and year (e.g. month@15 04:00); fixed durations such as 6h, 15m, or 10d start buckets at multiples of the duration from the Unix epoch, or an RFC3339 anchor such as 10d@2023-01-01T00:00:00Z, with the UTC start time as the key; and ADVANCED EXPRESSIONS may be used for complex strategies.
  - oldest or newest - whether the policy prefers older or newer entries. By default, newest entries are preferred.
+ - edges (or both) - keep both the oldest and newest entries of each bucket, with max applying to each side (e.g. by=month;edges keeps the first and last entry of each month).
+ - at={TARGET} - prefer the entry nearest a target within each bucket of a simple by value. Targets are {HH}:{MM} for day, {WEEKDAY} {HH}:{MM} for week (e.g. sun 02:00), {DAY} {HH}:{MM} for month, {MM}-{DD} {HH}:{MM} for year, and :{MM} for hour; the day may be omitted to use the first of the bucket.
+ - prefer={EXPR} - an expression to rank entries before time is considered; entries with higher values are preferred (e.g. int(fields[1]) for the largest size, or fields[2] == "complete" for complete entries). Expressions must evaluate to a bool, number, or string.
  - max={INT} - limit the policy to apply to, at most, {INT} entries. By default, if no other qualifier is used, a policy applies to all entries (max=-1); otherwise it defaults to a single entry (max=1).
+ - spacing={DURATION} - keep entries which are at least {DURATION} apart (e.g. 30d;spacing=6h), starting from the newest (or oldest) entry. When combined with by, spacing applies within each bucket and max defaults to all spaced entries.
+ - decay={FACTOR} - thin entries so density decreases with age (e.g. 1y;decay=2). Spacing starts at the spacing qualifier (default 1h) for entries less than 24 spacings old, and is multiplied by {FACTOR} for each following tier of age, which is also {FACTOR} times longer. With decay=2, this keeps about one per hour for a day, one per 2h for the next two days, one per 4h for the next four days, and so on.
+ - last={INT} - limit the policy to the {INT} most recent buckets which have entries, regardless of their age; whole buckets are evicted once more exist. Requires by. The Time Range may be omitted (e.g. by=day;last=7).
+ - fill - also keep the oldest entry evaluated while it is within range and fewer than last buckets have entries (or always, without last), as restic and borg do. Requires by.
+ - name={NAME} - a name used to refer to the policy in messages, annotations, reports, and metrics. By default, policies are named policy-N by their position, starting at 0.
 `, "", "    ", 120)
 
 			ctx.Stdout.Write([]byte("\n"))
@@ -67,6 +92,21 @@ For complex situations, use Common Expression Language (see https://github.com/g
 
 			ctx.Stdout.Write([]byte("\n"))
 
+			doc.ToText(
+				ctx.Stdout,
+				`REPORT SCHEMA
+
+Reports are versioned with the schema property (currently timepolicy/report/v1) and described by the JSON Schema in schema/report.v1.json of the source repository. The document contains:
+
+ - reference - time that policy ranges were evaluated relative to
+ - policies - list of policies with their name, spec, and cutoff (omitted when there is no range)
+ - entries - list of every entry with its offset, raw, time, decision (selected, pinned, excluded, or evicted), and claims (policy and bucket of each selecting policy)
+
+Enumerations such as decision may gain values within a version.
+`, "", "    ", 120)
+
+			ctx.Stdout.Write([]byte("\n"))
+
 			doc.ToText(
 				ctx.Stdout,
 				`EXIT STATUS
@@ -74,6 +114,8 @@ For complex situations, use Common Expression Language (see https://github.com/g
  - 0 - one or more entries were selected
  - 1 - no entries were selected
  - >1 - a general error occurred
+
+The check command instead exits 3 when the newest entry is too old, 4 when expected buckets are missing, and 5 when both occur.
 `, "", "    ", 120)
 
 			return nil
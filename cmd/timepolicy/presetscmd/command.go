@@ -0,0 +1,49 @@
+package presetscmd
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+)
+
+type Command struct {
+	List ListCommand `cmd:"" help:"List the names of presets."`
+	Show ShowCommand `cmd:"" help:"Print the policies of a preset in the format of a policy file."`
+}
+
+type ListCommand struct{}
+
+func (cmd *ListCommand) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	if appOptions.Quiet {
+		return nil
+	}
+
+	for _, name := range timepolicy.PresetNames() {
+		fmt.Fprintln(app.Stdout, name)
+	}
+
+	return nil
+}
+
+type ShowCommand struct {
+	Preset string `arg:"" placeholder:"NAME[:ARGS]" help:"Preset to expand, such as gfs:7d,4w,12m,5y."`
+}
+
+func (cmd *ShowCommand) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	specs, err := timepolicy.ExpandPreset(cmd.Preset)
+	if err != nil {
+		return err
+	}
+
+	if appOptions.Quiet {
+		return nil
+	}
+
+	for _, spec := range specs {
+		fmt.Fprintln(app.Stdout, spec)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package rootcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+var annotateValueColumns = map[string]struct{}{
+	"policy": {},
+	"bucket": {},
+	"age":    {},
+	"claims": {},
+	"pinned": {},
+}
+
+type AnnotateValue struct {
+	columns []string
+}
+
+var _ kong.MapperValue = &AnnotateValue{}
+
+func (v *AnnotateValue) Decode(ctx *kong.DecodeContext) error {
+	var raw string
+
+	err := ctx.Scan.PopValueInto("string", &raw)
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+
+	for _, column := range strings.Split(raw, ",") {
+		if _, known := annotateValueColumns[column]; !known {
+			return fmt.Errorf("unsupported column: %s", column)
+		}
+
+		columns = append(columns, column)
+	}
+
+	v.columns = columns
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package rootcmd
+
+import (
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+)
+
+type ByteSizeValue int64
+
+var _ kong.MapperValue = (*ByteSizeValue)(nil)
+
+func (v *ByteSizeValue) Decode(ctx *kong.DecodeContext) error {
+	var raw string
+
+	err := ctx.Scan.PopValueInto("string", &raw)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := timepolicy.ParseByteSize(raw)
+	if err != nil {
+		return err
+	}
+
+	*v = ByteSizeValue(parsed)
+
+	return nil
+}
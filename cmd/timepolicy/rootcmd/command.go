@@ -1,11 +1,13 @@
 package rootcmd
 
 import (
-	"bufio"
-	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/dpb587/timepolicy"
@@ -13,66 +15,55 @@ import (
 )
 
 type Command struct {
-	FieldCount     int                  `name:"field-count" help:"Limit the number of fields extracted per entry."`
-	FieldSeparator *FieldSeparatorValue `name:"field-separator" short:"F" placeholder:"STRING" help:"Separator used between fields. Value should be a regular expression (see https://pkg.go.dev/regexp/syntax) or a supported alias (csv, spaces, tsv). Default is spaces."`
-	Read           io.Reader            `name:"read-from" short:"i" placeholder:"PATH" type:"path" help:"Read entries from file or path. Default is stdin."`
-	Write          io.Writer            `name:"write-to" short:"o" placeholder:"PATH" type:"path" help:"Write selected entries to file or path. Default is stdout."`
-	WriteFormat    *WriteFormatValue    `name:"write" placeholder:"FORMAT" help:"Write selected entries in a custom format. A single field may be output using dollar + field number, such as $1 for the first field."`
-	Policies       PolicyValueList      `name:"policy" short:"p" placeholder:"STRING..." help:"One or more policies to evaluate entries against. See POLICY SPECIFICATIONS."`
-	Invert         bool                 `name:"invert" help:"Show entries which are not covered by any policy. Enables streaming mode and entries may be written in a different order than they were read."`
-	TimeFormat     *TimeFormatValue     `name:"time" placeholder:"STRING" help:"Format used by the time field. Value should be a custom layout (see https://pkg.go.dev/time#Layout) or a supported alias (ANSIC, UnixDate, RubyDate, RFC822, RFC822Z, RFC850, RFC1123, RFC1123Z, RFC3339, RFC3339Nano, Stamp, StampMilli, StampMicro, StampNano, Unix, UnixMilli, and YYYY-MM-DD). Default is RFC3339."`
-	TimeField      int                  `name:"time-field" placeholder:"INT" help:"Field number containing the time, such as 1 for the first field."`
+	InputOptions  `embed:""`
+	PolicyOptions `embed:""`
+	PinOptions    `embed:""`
+
+	Annotate     *AnnotateValue    `name:"annotate" placeholder:"COLUMN,..." help:"Append columns describing the decision to each written entry. Supported columns are: policy (names of the policies selecting the entry), bucket (bucket keys of the selecting policies), age (relative to the current time), claims (number of policies selecting the entry), and pinned (true or false)."`
+	Write        io.Writer         `name:"write-to" short:"o" placeholder:"PATH" type:"path" help:"Write selected entries to file or path. Default is stdout."`
+	WriteFormat  *WriteFormatValue `name:"write" placeholder:"FORMAT" help:"Write selected entries in a custom format. Fields may be output using dollar + field number, such as $1 for the first field, and multiple fields may be separated by commas, such as $1,$3. When reading csv or tsv, fields are written with the same separator and quoting."`
+	Stats        bool              `name:"stats" help:"Print totals of entries and per-policy counts to stderr after evaluating."`
+	Report       string            `name:"report" placeholder:"PATH" type:"path" help:"Write a report of the policies, every entry, and its decision to a file. See REPORT SCHEMA."`
+	ReportFormat string            `name:"report-format" enum:"json,yaml" default:"json" help:"Format used by the report file (${enum})."`
+	MetricsFile  string            `name:"metrics-file" placeholder:"PATH" type:"path" help:"Write metrics in the Prometheus text format to a file, such as for the node_exporter textfile collector. The file is replaced atomically."`
+	Budget       ByteSizeValue     `name:"budget" placeholder:"SIZE" help:"Evict selected entries until the total of --size-field is at most SIZE (e.g. 500GiB). Entries selected only by later policies are evicted first, oldest first; pinned entries and the --budget-keep newest entries are never evicted."`
+	SizeField    FieldValue        `name:"size-field" placeholder:"FIELD" help:"Field with the size of each entry for --budget, such as $2. Sizes may use units such as 1.5G, 200MB, or 4KiB."`
+	BudgetKeep   int               `name:"budget-keep" default:"1" placeholder:"INT" help:"Number of the newest selected entries which are never evicted by --budget."`
+	Invert       bool              `name:"invert" help:"Show entries which are not covered by any policy. Enables streaming mode and entries may be written in a different order than they were read."`
 }
 
 func (cmd *Command) BeforeApply() error {
-	cmd.FieldSeparator = &FieldSeparatorValue{
-		f: timepolicy.SpacesEntryFieldSplitter,
-	}
-	cmd.Read = os.Stdin
+	cmd.InputOptions.SetDefaults()
+	cmd.Annotate = &AnnotateValue{}
 	cmd.Write = os.Stdout
-	cmd.WriteFormat = &WriteFormatValue{
-		builder: func(w io.Writer) timepolicy.EntryWriter {
-			return timepolicy.NewEntryWriter(w)
-		},
-	}
-	cmd.TimeFormat = &TimeFormatValue{
-		f: timeFormatValueEnums["RFC3339"],
-	}
+	cmd.WriteFormat = &WriteFormatValue{}
 
 	return nil
 }
 
 func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
-	var input timepolicy.EntryScanner
+	var reference = timepolicy.Now()
 
-	var fieldCount = -1
-	if cmd.FieldCount > 0 {
-		fieldCount = cmd.FieldCount
+	policies, err := cmd.PolicySpecs(reference)
+	if err != nil {
+		return err
 	}
 
-	if cmd.FieldSeparator.csvApplier != nil {
-		r := csv.NewReader(cmd.Read)
-		cmd.FieldSeparator.csvApplier(r)
-		r.FieldsPerRecord = fieldCount
-
-		input = timepolicy.NewCSVEntryScanner(
-			r,
-			cmd.TimeField,
-			cmd.TimeFormat.f,
-		)
-	} else {
-		s := bufio.NewScanner(cmd.Read)
-
-		input = timepolicy.NewGenericEntryScanner(
-			s,
-			cmd.FieldSeparator.f,
-			fieldCount,
-			cmd.TimeField,
-			cmd.TimeFormat.f,
-		)
+	if cmd.Budget > 0 && cmd.SizeField == 0 {
+		return errors.New("budget: --size-field is required")
+	} else if cmd.SizeField != 0 && cmd.Budget == 0 {
+		return errors.New("budget: --size-field requires --budget")
+	} else if cmd.BudgetKeep < 0 {
+		return errors.New("budget: --budget-keep must not be negative")
 	}
 
-	selectedWriter := cmd.WriteFormat.builder(cmd.Write)
+	var policySelections *timepolicy.PolicySelectionSet
+
+	selectedWriter := cmd.NewEntryWriter(cmd.Write, cmd.WriteFormat.fields, cmd.newAnnotateColumns(reference, func(e *timepolicy.Entry) []timepolicy.PolicyClaim {
+		return policySelections.EntryClaims(e)
+	}, func(e *timepolicy.Entry) bool {
+		return policySelections.IsPinned(e)
+	}))
 	evictedWriter := timepolicy.NewDiscardEntryWriter()
 
 	if appOptions.Quiet {
@@ -86,25 +77,104 @@ func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
 
 	//
 
-	policySelections := timepolicy.NewPolicySelectionSet(cmd.Policies.values, evictedWriter)
+	policySelections = cmd.NewPolicySelectionSet(policies, evictedWriter)
+
+	err = cmd.ApplyPins(policySelections)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.ApplyExcludes(policySelections, reference)
+	if err != nil {
+		return err
+	}
 
 	//
 
-	for input.Scan() {
-		var entry = input.Entry()
+	var entriesRead int
+	var entryNewest time.Time
+	var reportEntries []*timepolicy.Entry
+	var reportEntryOffsets []int
+
+	entriesUnparseable, err := cmd.ReadEntries(func(offset int, entry *timepolicy.Entry) error {
+		entriesRead++
+
+		if entryNewest.IsZero() || entry.Time.After(entryNewest) {
+			entryNewest = entry.Time
+		}
+
+		if cmd.Report != "" {
+			reportEntries = append(reportEntries, entry)
+			reportEntryOffsets = append(reportEntryOffsets, offset)
+		}
 
 		entrySelected, err := policySelections.EvaluateEntry(entry)
 		if err != nil {
-			return fmt.Errorf("processing entry %d: %v", input.EntryOffset()+1, err)
+			return fmt.Errorf("processing entry %d: %v", offset, err)
 		} else if !entrySelected {
 			evictedWriter.WriteEntry(entry)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if err := input.Err(); err != nil {
+	err = policySelections.Flush()
+	if err != nil {
 		return err
 	}
 
+	if cmd.Budget > 0 {
+		total, err := policySelections.EnforceBudget(int64(cmd.Budget), cmd.BudgetKeep, timepolicy.NewFieldEntrySizeFunc(int(cmd.SizeField)-1))
+		if err != nil {
+			return fmt.Errorf("budget: %v", err)
+		} else if total > int64(cmd.Budget) && !appOptions.Quiet {
+			fmt.Fprintf(app.Stderr, "budget: selected entries total %d which exceeds %d, but no more entries may be evicted\n", total, cmd.Budget)
+		}
+	}
+
+	if cmd.Report != "" {
+		report := newReport(reference, policies)
+
+		for entryIdx, entry := range reportEntries {
+			report.AddEntry(reportEntryOffsets[entryIdx], entry, reportDecision(policySelections, entry), policySelections.EntryClaims(entry))
+		}
+
+		err := cmd.writeReport(report)
+		if err != nil {
+			return fmt.Errorf("writing report: %v", err)
+		}
+	}
+
+	if cmd.MetricsFile != "" {
+		m := &metrics{
+			stats:             newStats(entriesRead, entriesUnparseable, policySelections),
+			NewestAge:         -1,
+			OldestSelectedAge: -1,
+		}
+
+		if !entryNewest.IsZero() {
+			m.NewestAge = reference.Sub(entryNewest)
+		}
+
+		for _, entry := range policySelections.Entries() {
+			if age := reference.Sub(entry.Time); age > m.OldestSelectedAge {
+				m.OldestSelectedAge = age
+			}
+		}
+
+		err := m.writeFile(cmd.MetricsFile)
+		if err != nil {
+			return fmt.Errorf("writing metrics: %v", err)
+		}
+	}
+
+	if cmd.Stats {
+		newStats(entriesRead, entriesUnparseable, policySelections).WriteTo(app.Stderr)
+	}
+
 	//
 
 	if cmd.Invert {
@@ -126,3 +196,61 @@ func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
 
 	return nil
 }
+
+func (cmd *Command) newAnnotateColumns(reference time.Time, claimsFunc func(e *timepolicy.Entry) []timepolicy.PolicyClaim, pinnedFunc func(e *timepolicy.Entry) bool) timepolicy.EntryColumnsFunc {
+	if len(cmd.Annotate.columns) == 0 {
+		return nil
+	}
+
+	return func(e *timepolicy.Entry) ([]string, error) {
+		var values []string
+		var claims = claimsFunc(e)
+
+		for _, column := range cmd.Annotate.columns {
+			switch column {
+			case "policy", "bucket":
+				var claimValues []string
+
+				for _, claim := range claims {
+					if column == "policy" {
+						claimValues = append(claimValues, claim.Spec.Name())
+					} else if claim.Bucket == "" {
+						claimValues = append(claimValues, "-")
+					} else {
+						claimValues = append(claimValues, claim.Bucket)
+					}
+				}
+
+				if len(claimValues) == 0 {
+					values = append(values, "-")
+				} else {
+					values = append(values, strings.Join(claimValues, ","))
+				}
+			case "age":
+				values = append(values, reference.Sub(e.Time).Truncate(time.Second).String())
+			case "claims":
+				values = append(values, strconv.Itoa(len(claims)))
+			case "pinned":
+				values = append(values, strconv.FormatBool(pinnedFunc(e)))
+			}
+		}
+
+		return values, nil
+	}
+}
+
+func (cmd *Command) writeReport(report *Report) error {
+	fh, err := os.Create(cmd.Report)
+	if err != nil {
+		return err
+	}
+
+	defer fh.Close()
+
+	err = report.Encode(fh, cmd.ReportFormat)
+	if err != nil {
+		return err
+	}
+
+	return fh.Close()
+}
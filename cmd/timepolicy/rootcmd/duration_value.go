@@ -0,0 +1,30 @@
+package rootcmd
+
+import (
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy/internal"
+)
+
+type DurationValue time.Duration
+
+var _ kong.MapperValue = (*DurationValue)(nil)
+
+func (v *DurationValue) Decode(ctx *kong.DecodeContext) error {
+	var raw string
+
+	err := ctx.Scan.PopValueInto("string", &raw)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := internal.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+
+	*v = DurationValue(parsed)
+
+	return nil
+}
@@ -1,7 +1,6 @@
 package rootcmd
 
 import (
-	"encoding/csv"
 	"regexp"
 
 	"github.com/alecthomas/kong"
@@ -9,8 +8,8 @@ import (
 )
 
 type FieldSeparatorValue struct {
-	csvApplier func(r *csv.Reader)
-	f          timepolicy.EntryFieldSplitterFunc
+	csvComma rune
+	f        timepolicy.EntryFieldSplitterFunc
 }
 
 var _ kong.MapperValue = &FieldSeparatorValue{}
@@ -28,15 +27,11 @@ func (v *FieldSeparatorValue) Decode(ctx *kong.DecodeContext) error {
 
 		return nil
 	} else if raw == "csv" {
-		v.csvApplier = func(r *csv.Reader) {
-			r.Comma = ','
-		}
+		v.csvComma = ','
 
 		return nil
 	} else if raw == "tsv" {
-		v.csvApplier = func(r *csv.Reader) {
-			r.Comma = '\t'
-		}
+		v.csvComma = '\t'
 
 		return nil
 	}
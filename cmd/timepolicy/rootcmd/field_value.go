@@ -0,0 +1,41 @@
+package rootcmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy/internal"
+)
+
+// FieldValue is a field number, such as $2, starting at 1. It is 0 when not
+// configured.
+type FieldValue int
+
+var _ kong.MapperValue = (*FieldValue)(nil)
+
+func (v *FieldValue) Decode(ctx *kong.DecodeContext) error {
+	var raw string
+
+	err := ctx.Scan.PopValueInto("string", &raw)
+	if err != nil {
+		return err
+	}
+
+	if !internal.DollarFieldRegExp.MatchString(raw) {
+		return errors.New("unsupported value")
+	}
+
+	fieldColumn, err := strconv.ParseInt(strings.TrimPrefix(raw, "$"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing field number: %v", err)
+	} else if fieldColumn < 1 {
+		return errors.New("expected field number to be greater than 0")
+	}
+
+	*v = FieldValue(fieldColumn)
+
+	return nil
+}
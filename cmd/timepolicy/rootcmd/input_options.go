@@ -0,0 +1,104 @@
+package rootcmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/dpb587/timepolicy"
+)
+
+type InputOptions struct {
+	FieldCount      int                  `name:"field-count" help:"Limit the number of fields extracted per entry."`
+	FieldSeparator  *FieldSeparatorValue `name:"field-separator" short:"F" placeholder:"STRING" help:"Separator used between fields. Value should be a regular expression (see https://pkg.go.dev/regexp/syntax) or a supported alias (csv, spaces, tsv). Default is spaces."`
+	Read            io.Reader            `name:"read-from" short:"i" placeholder:"PATH" type:"path" help:"Read entries from file or path. Default is stdin."`
+	SkipUnparseable bool                 `name:"skip-unparseable" help:"Skip entries which cannot be parsed instead of failing."`
+	TimeFormat      *TimeFormatValue     `name:"time" placeholder:"STRING" help:"Format used by the time field. Value should be a custom layout (see https://pkg.go.dev/time#Layout) or a supported alias (ANSIC, UnixDate, RubyDate, RFC822, RFC822Z, RFC850, RFC1123, RFC1123Z, RFC3339, RFC3339Nano, Stamp, StampMilli, StampMicro, StampNano, Unix, UnixMilli, and YYYY-MM-DD). Default is RFC3339."`
+	TimeField       int                  `name:"time-field" placeholder:"INT" help:"Field number containing the time, such as 1 for the first field."`
+}
+
+func (opts *InputOptions) SetDefaults() {
+	opts.FieldSeparator = &FieldSeparatorValue{
+		f: timepolicy.SpacesEntryFieldSplitter,
+	}
+	opts.Read = os.Stdin
+	opts.TimeFormat = &TimeFormatValue{
+		f: timeFormatValueEnums["RFC3339"],
+	}
+}
+
+func (opts *InputOptions) NewEntryScanner() timepolicy.EntryScanner {
+	var fieldCount = -1
+	if opts.FieldCount > 0 {
+		fieldCount = opts.FieldCount
+	}
+
+	if opts.FieldSeparator.csvComma != 0 {
+		return timepolicy.NewCSVRecordEntryScanner(
+			opts.Read,
+			func(r *csv.Reader) {
+				r.Comma = opts.FieldSeparator.csvComma
+				r.FieldsPerRecord = fieldCount
+			},
+			opts.TimeField,
+			opts.TimeFormat.f,
+		)
+	}
+
+	return timepolicy.NewGenericEntryScanner(
+		bufio.NewScanner(opts.Read),
+		opts.FieldSeparator.f,
+		fieldCount,
+		opts.TimeField,
+		opts.TimeFormat.f,
+	)
+}
+
+// ReadEntries calls f for every entry of the input with its offset, starting
+// at 1. The number of entries skipped as unparseable is returned.
+func (opts *InputOptions) ReadEntries(f func(offset int, e *timepolicy.Entry) error) (int, error) {
+	var unparseable int
+
+	input := opts.NewEntryScanner()
+
+	for {
+		if !input.Scan() {
+			err := input.Err()
+			if err == nil {
+				break
+			}
+
+			var parseErr *timepolicy.EntryParseError
+
+			if opts.SkipUnparseable && errors.As(err, &parseErr) {
+				unparseable++
+
+				continue
+			}
+
+			return unparseable, err
+		}
+
+		err := f(input.EntryOffset()+1, input.Entry())
+		if err != nil {
+			return unparseable, err
+		}
+	}
+
+	return unparseable, nil
+}
+
+// NewEntryWriter returns a writer using the same field separator as the input.
+func (opts *InputOptions) NewEntryWriter(w io.Writer, fields []int, columns timepolicy.EntryColumnsFunc) timepolicy.EntryWriter {
+	if opts.FieldSeparator.csvComma != 0 {
+		return timepolicy.NewCSVEntryWriter(w, opts.FieldSeparator.csvComma, fields, columns)
+	} else if columns != nil {
+		return timepolicy.NewEntryColumnsWriter(w, fields, columns)
+	} else if len(fields) > 0 {
+		return timepolicy.NewEntryFieldWriter(w, fields...)
+	}
+
+	return timepolicy.NewEntryWriter(w)
+}
@@ -0,0 +1,92 @@
+package rootcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type metrics struct {
+	*stats
+
+	// NewestAge is the age of the newest entry read, or negative when no entries
+	// were read.
+	NewestAge time.Duration
+
+	// OldestSelectedAge is the age of the oldest entry selected, or negative when
+	// no entries were selected.
+	OldestSelectedAge time.Duration
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *metrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	m.writeGauge(&b, "timepolicy_entries_read", "Number of entries read.", float64(m.EntriesRead))
+	m.writeGauge(&b, "timepolicy_entries_selected", "Number of entries selected by one or more policies, excluding pinned entries.", float64(m.EntriesSelected))
+	m.writeGauge(&b, "timepolicy_entries_pinned", "Number of entries selected by a pin.", float64(m.EntriesPinned))
+	m.writeGauge(&b, "timepolicy_entries_excluded", "Number of entries matching an exclusion.", float64(m.EntriesExcluded))
+	m.writeGauge(&b, "timepolicy_entries_evicted", "Number of entries which were not selected, pinned, or excluded.", float64(m.EntriesEvicted))
+	m.writeGauge(&b, "timepolicy_entries_unparseable", "Number of entries skipped because they could not be parsed.", float64(m.EntriesUnparseable))
+
+	if m.NewestAge >= 0 {
+		m.writeGauge(&b, "timepolicy_newest_entry_age_seconds", "Age of the newest entry read.", m.NewestAge.Seconds())
+	}
+
+	if m.OldestSelectedAge >= 0 {
+		m.writeGauge(&b, "timepolicy_oldest_selected_entry_age_seconds", "Age of the oldest entry selected.", m.OldestSelectedAge.Seconds())
+	}
+
+	fmt.Fprintf(&b, "# HELP timepolicy_policy_buckets Number of buckets populated by the policy.\n# TYPE timepolicy_policy_buckets gauge\n")
+
+	for _, policy := range m.Policies {
+		fmt.Fprintf(&b, "timepolicy_policy_buckets{policy=\"%s\"} %d\n", metricsLabelEscaper.Replace(policy.Name), policy.Buckets)
+	}
+
+	fmt.Fprintf(&b, "# HELP timepolicy_policy_selected_entries Number of entries selected by the policy.\n# TYPE timepolicy_policy_selected_entries gauge\n")
+
+	for _, policy := range m.Policies {
+		fmt.Fprintf(&b, "timepolicy_policy_selected_entries{policy=\"%s\"} %d\n", metricsLabelEscaper.Replace(policy.Name), policy.Selected)
+	}
+
+	n, err := io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+func (m *metrics) writeGauge(b *strings.Builder, name, help string, value float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+}
+
+// writeFile replaces path atomically so collectors never read a partial file.
+func (m *metrics) writeFile(path string) error {
+	fh, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(fh.Name())
+	defer fh.Close()
+
+	_, err = m.WriteTo(fh)
+	if err != nil {
+		return err
+	}
+
+	err = fh.Chmod(0644)
+	if err != nil {
+		return err
+	}
+
+	err = fh.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(fh.Name(), path)
+}
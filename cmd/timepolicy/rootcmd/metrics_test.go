@@ -0,0 +1,110 @@
+package rootcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dpb587/timepolicy"
+)
+
+func TestMetrics(t *testing.T) {
+	m := &metrics{
+		stats: &stats{
+			EntriesRead:        10,
+			EntriesSelected:    4,
+			EntriesPinned:      1,
+			EntriesExcluded:    2,
+			EntriesEvicted:     3,
+			EntriesUnparseable: 1,
+			Policies: []policyStats{
+				{
+					Name:                 "daily",
+					PolicySelectionStats: timepolicy.PolicySelectionStats{Matched: 7, Buckets: 3, Selected: 3},
+				},
+				{
+					Name:                 "say \"hi\" \\ bye\n",
+					PolicySelectionStats: timepolicy.PolicySelectionStats{Matched: 9, Buckets: 1, Selected: 1},
+				},
+			},
+		},
+		NewestAge:         90 * time.Minute,
+		OldestSelectedAge: -1,
+	}
+
+	expected := `# HELP timepolicy_entries_read Number of entries read.
+# TYPE timepolicy_entries_read gauge
+timepolicy_entries_read 10
+# HELP timepolicy_entries_selected Number of entries selected by one or more policies, excluding pinned entries.
+# TYPE timepolicy_entries_selected gauge
+timepolicy_entries_selected 4
+# HELP timepolicy_entries_pinned Number of entries selected by a pin.
+# TYPE timepolicy_entries_pinned gauge
+timepolicy_entries_pinned 1
+# HELP timepolicy_entries_excluded Number of entries matching an exclusion.
+# TYPE timepolicy_entries_excluded gauge
+timepolicy_entries_excluded 2
+# HELP timepolicy_entries_evicted Number of entries which were not selected, pinned, or excluded.
+# TYPE timepolicy_entries_evicted gauge
+timepolicy_entries_evicted 3
+# HELP timepolicy_entries_unparseable Number of entries skipped because they could not be parsed.
+# TYPE timepolicy_entries_unparseable gauge
+timepolicy_entries_unparseable 1
+# HELP timepolicy_newest_entry_age_seconds Age of the newest entry read.
+# TYPE timepolicy_newest_entry_age_seconds gauge
+timepolicy_newest_entry_age_seconds 5400
+# HELP timepolicy_policy_buckets Number of buckets populated by the policy.
+# TYPE timepolicy_policy_buckets gauge
+timepolicy_policy_buckets{policy="daily"} 3
+timepolicy_policy_buckets{policy="say \"hi\" \\ bye\n"} 1
+# HELP timepolicy_policy_selected_entries Number of entries selected by the policy.
+# TYPE timepolicy_policy_selected_entries gauge
+timepolicy_policy_selected_entries{policy="daily"} 3
+timepolicy_policy_selected_entries{policy="say \"hi\" \\ bye\n"} 1
+`
+
+	buf := bytes.NewBuffer(nil)
+
+	if _, err := m.WriteTo(buf); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := expected, buf.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "timepolicy.prom")
+
+	if err := os.WriteFile(path, []byte("stale\n"), 0600); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if err := m.writeFile(path); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := expected, string(written); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := os.FileMode(0644), info.Mode().Perm(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	// temporary files are renamed or removed
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := 1, len(files); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	if err := m.writeFile(filepath.Join(dir, "missing", "timepolicy.prom")); err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
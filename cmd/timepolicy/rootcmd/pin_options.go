@@ -0,0 +1,66 @@
+package rootcmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dpb587/timepolicy"
+)
+
+type PinOptions struct {
+	Pins     []string `name:"pin" sep:"none" placeholder:"EXPR..." help:"One or more expressions for entries which are always selected and never evicted, such as for legal holds. See ADVANCED EXPRESSIONS."`
+	HoldFile string   `name:"hold-file" placeholder:"PATH" type:"existingfile" help:"Read raw entries or field values of entries which are always selected and never evicted from a file, one per line. Empty lines and lines starting with # are ignored."`
+}
+
+// ApplyPins configures the pins of the options on a selection set.
+func (opts *PinOptions) ApplyPins(pss *timepolicy.PolicySelectionSet) error {
+	for pinIdx, pin := range opts.Pins {
+		matcher, err := timepolicy.NewExpressionEntryMatcher(pin)
+		if err != nil {
+			return fmt.Errorf("parsing pin %d: %v", pinIdx, err)
+		}
+
+		pss.Pin(matcher)
+	}
+
+	if opts.HoldFile != "" {
+		keys, err := readHoldFile(opts.HoldFile)
+		if err != nil {
+			return fmt.Errorf("reading hold file: %v", err)
+		}
+
+		pss.Pin(timepolicy.NewKeyEntryMatcher(keys))
+	}
+
+	return nil
+}
+
+func readHoldFile(path string) ([]string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fh.Close()
+
+	var keys []string
+
+	s := bufio.NewScanner(fh)
+
+	for s.Scan() {
+		raw := strings.TrimSpace(s.Text())
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+
+		keys = append(keys, raw)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,152 @@
+package rootcmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dpb587/timepolicy"
+)
+
+type PolicyOptions struct {
+	Policies   PolicyValueList `name:"policy" short:"p" placeholder:"STRING..." help:"One or more policies to evaluate entries against. See POLICY SPECIFICATIONS."`
+	Presets    []string        `name:"preset" sep:"none" placeholder:"NAME[:ARGS]..." help:"One or more named sets of policies, such as gfs:7d,4w,12m,5y, sanoid-production, or time-machine. See the presets command."`
+	PolicyFile string          `name:"policy-file" placeholder:"PATH" type:"existingfile" help:"Read additional policies from a file, one per line. Empty lines and lines starting with # are ignored."`
+	Exclusive  bool            `name:"exclusive" help:"Allow each entry to be selected by only one policy, so each policy contributes distinct entries. Policies are prioritized in the order they are configured, and entries evicted by a policy are offered to later policies."`
+	Excludes   []string        `name:"exclude" sep:"none" placeholder:"STRING..." help:"One or more policies of entries which are never selected and never consume a bucket, such as failed backups. Only a range, if, and name may be configured."`
+}
+
+// PolicySpecs returns the policies of flags followed by those of presets and
+// the policy file, resolved relative to reference.
+func (opts *PolicyOptions) PolicySpecs(reference time.Time) ([]*timepolicy.PolicySpec, error) {
+	return LoadPolicySpecs(opts.Policies, opts.Presets, opts.PolicyFile, reference)
+}
+
+// NewPolicySelectionSet returns a selection set for policies configured with the
+// exclusive mode of the options.
+func (opts *PolicyOptions) NewPolicySelectionSet(policies []*timepolicy.PolicySpec, evictions timepolicy.EntryWriter) *timepolicy.PolicySelectionSet {
+	pss := timepolicy.NewPolicySelectionSet(policies, evictions)
+	pss.SetExclusive(opts.Exclusive)
+
+	return pss
+}
+
+// ApplyExcludes configures the exclusions of the options on a selection set,
+// resolved relative to reference.
+func (opts *PolicyOptions) ApplyExcludes(pss *timepolicy.PolicySelectionSet, reference time.Time) error {
+	return ApplyExcludes(pss, opts.Excludes, reference)
+}
+
+// ApplyExcludes configures exclusions on a selection set, resolved relative to
+// reference.
+func ApplyExcludes(pss *timepolicy.PolicySelectionSet, excludes []string, reference time.Time) error {
+	for excludeIdx, excludeRaw := range excludes {
+		spec, err := timepolicy.ParsePolicySpecString(fmt.Sprintf("exclude-%d", excludeIdx), excludeRaw)
+		if err != nil {
+			return fmt.Errorf("parsing exclude %d: %v", excludeIdx, err)
+		}
+
+		matcher, err := timepolicy.NewExclusionEntryMatcher(spec.Resolve(reference))
+		if err != nil {
+			return fmt.Errorf("parsing exclude %d: %v", excludeIdx, err)
+		}
+
+		pss.Exclude(matcher)
+	}
+
+	return nil
+}
+
+// LoadPolicySpecs returns the policies of flags followed by those of presets and
+// the policy file, if any, resolved relative to reference.
+func LoadPolicySpecs(policies PolicyValueList, presets []string, policyFile string, reference time.Time) ([]*timepolicy.PolicySpec, error) {
+	var specs []*timepolicy.PolicySpec
+
+	specs = append(specs, policies.values...)
+
+	for _, preset := range presets {
+		raws, err := timepolicy.ExpandPreset(preset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, raw := range raws {
+			spec, err := timepolicy.ParsePolicySpecString(fmt.Sprintf("policy-%d", len(specs)), raw)
+			if err != nil {
+				return nil, fmt.Errorf("parsing preset %s: %v", preset, err)
+			}
+
+			specs = append(specs, spec)
+		}
+	}
+
+	if policyFile != "" {
+		lines, err := ReadPolicyFile(policyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range lines {
+			spec, err := timepolicy.ParsePolicySpecString(fmt.Sprintf("policy-%d", len(specs)), line.Raw)
+			if err != nil {
+				return nil, fmt.Errorf("parsing policy file: line %d: %v", line.Number, err)
+			}
+
+			specs = append(specs, spec)
+		}
+	}
+
+	names := map[string]struct{}{}
+
+	for specIdx, spec := range specs {
+		if _, known := names[spec.Name()]; known {
+			return nil, fmt.Errorf("policy %s: name is already used by another policy", spec.Name())
+		}
+
+		names[spec.Name()] = struct{}{}
+		specs[specIdx] = spec.Resolve(reference)
+	}
+
+	return specs, nil
+}
+
+type PolicyFileLine struct {
+	Number int
+	Raw    string
+}
+
+func ReadPolicyFile(path string) ([]PolicyFileLine, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %v", err)
+	}
+
+	defer fh.Close()
+
+	var lines []PolicyFileLine
+	var lineNumber int
+
+	s := bufio.NewScanner(fh)
+
+	for s.Scan() {
+		lineNumber++
+
+		raw := strings.TrimSpace(s.Text())
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+
+		lines = append(lines, PolicyFileLine{
+			Number: lineNumber,
+			Raw:    raw,
+		})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("reading policy file: %v", err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,37 @@
+package rootcmd
+
+import (
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+)
+
+type RangeValue struct {
+	start func(reference time.Time) time.Time
+}
+
+var _ kong.MapperValue = &RangeValue{}
+
+func (v *RangeValue) Decode(ctx *kong.DecodeContext) error {
+	var raw string
+
+	err := ctx.Scan.PopValueInto("string", &raw)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := timepolicy.ParseRange(raw)
+	if err != nil {
+		return err
+	}
+
+	v.start = parsed
+
+	return nil
+}
+
+// Start returns the start of the range ending at reference.
+func (v *RangeValue) Start(reference time.Time) time.Time {
+	return v.start(reference)
+}
@@ -0,0 +1,127 @@
+package rootcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dpb587/timepolicy"
+	"gopkg.in/yaml.v3"
+)
+
+// ReportSchema identifies the version of the report document. It must be
+// changed whenever the document changes incompatibly; adding enumeration values,
+// such as decisions, is compatible. See schema/report.v1.json.
+const ReportSchema = "timepolicy/report/v1"
+
+type Report struct {
+	Schema    string         `json:"schema" yaml:"schema"`
+	Reference time.Time      `json:"reference" yaml:"reference"`
+	Policies  []ReportPolicy `json:"policies" yaml:"policies"`
+	Entries   []ReportEntry  `json:"entries" yaml:"entries"`
+}
+
+type ReportPolicy struct {
+	Name    string     `json:"name" yaml:"name"`
+	Spec    string     `json:"spec" yaml:"spec"`
+	Comment string     `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Cutoff  *time.Time `json:"cutoff,omitempty" yaml:"cutoff,omitempty"`
+}
+
+type ReportEntry struct {
+	Offset   int           `json:"offset" yaml:"offset"`
+	Raw      string        `json:"raw" yaml:"raw"`
+	Time     time.Time     `json:"time" yaml:"time"`
+	Decision string        `json:"decision" yaml:"decision"`
+	Claims   []ReportClaim `json:"claims,omitempty" yaml:"claims,omitempty"`
+}
+
+type ReportClaim struct {
+	Policy string `json:"policy" yaml:"policy"`
+	Bucket string `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+}
+
+const (
+	ReportDecisionSelected = "selected"
+	ReportDecisionPinned   = "pinned"
+	ReportDecisionExcluded = "excluded"
+	ReportDecisionEvicted  = "evicted"
+)
+
+func reportDecision(pss *timepolicy.PolicySelectionSet, e *timepolicy.Entry) string {
+	if pss.IsPinned(e) {
+		return ReportDecisionPinned
+	} else if pss.IsExcluded(e) {
+		return ReportDecisionExcluded
+	} else if len(pss.EntryClaims(e)) > 0 {
+		return ReportDecisionSelected
+	}
+
+	return ReportDecisionEvicted
+}
+
+func newReport(reference time.Time, policies []*timepolicy.PolicySpec) *Report {
+	report := &Report{
+		Schema:    ReportSchema,
+		Reference: reference,
+		Policies:  []ReportPolicy{},
+		Entries:   []ReportEntry{},
+	}
+
+	for _, policy := range policies {
+		reportPolicy := ReportPolicy{
+			Name:    policy.Name(),
+			Spec:    policy.String(),
+			Comment: policy.Comment(),
+		}
+
+		if cutoff := policy.Cutoff(); !cutoff.IsZero() {
+			reportPolicy.Cutoff = &cutoff
+		}
+
+		report.Policies = append(report.Policies, reportPolicy)
+	}
+
+	return report
+}
+
+func (r *Report) AddEntry(offset int, e *timepolicy.Entry, decision string, claims []timepolicy.PolicyClaim) {
+	reportEntry := ReportEntry{
+		Offset:   offset,
+		Raw:      e.Raw,
+		Time:     e.Time,
+		Decision: decision,
+	}
+
+	for _, claim := range claims {
+		reportEntry.Claims = append(reportEntry.Claims, ReportClaim{
+			Policy: claim.Spec.Name(),
+			Bucket: claim.Bucket,
+		})
+	}
+
+	r.Entries = append(r.Entries, reportEntry)
+}
+
+func (r *Report) Encode(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(r)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+
+		err := encoder.Encode(r)
+		if err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	}
+
+	return fmt.Errorf("unsupported format: %s", format)
+}
@@ -0,0 +1,229 @@
+package rootcmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpb587/timepolicy"
+	"gopkg.in/yaml.v3"
+)
+
+func TestReportSchema(t *testing.T) {
+	timepolicy.Now = func() time.Time {
+		return time.Date(2023, 1, 1, 1, 2, 3, 0, time.UTC)
+	}
+
+	schemaBytes, err := os.ReadFile(filepath.Join("..", "..", "..", "schema", "report.v1.json"))
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	var schema map[string]interface{}
+
+	if err := json.Unmarshal(schemaBytes, &schema); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	daily, err := timepolicy.ParsePolicySpecString("policy-0", "7d;by=day;name=daily // keep newest by day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	all, err := timepolicy.ParsePolicySpecString("policy-1", "max=1")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	policies := []*timepolicy.PolicySpec{daily.Resolve(timepolicy.Now()), all.Resolve(timepolicy.Now())}
+	pss := timepolicy.NewPolicySelectionSet(policies, timepolicy.NewDiscardEntryWriter())
+	pss.Pin(func(e *timepolicy.Entry) (bool, error) {
+		return e.Fields[1] == "held", nil
+	})
+	pss.Exclude(func(e *timepolicy.Entry) (bool, error) {
+		return e.Fields[1] == "failed", nil
+	})
+
+	var entries []*timepolicy.Entry
+
+	for _, raw := range []string{
+		"2022-12-31T18:00:00Z ok",
+		"2022-12-31T06:00:00Z ok",
+		"2022-12-30T06:00:00Z failed",
+		"2022-01-01T06:00:00Z held",
+	} {
+		fields := strings.Fields(raw)
+		e := &timepolicy.Entry{Raw: raw, Fields: fields, Time: mustParseRFC3339(fields[0])}
+
+		if _, err := pss.EvaluateEntry(e); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	report := newReport(timepolicy.Now(), policies)
+
+	for entryIdx, e := range entries {
+		report.AddEntry(entryIdx+1, e, reportDecision(pss, e), pss.EntryClaims(e))
+	}
+
+	var decisions []string
+
+	for _, reportEntry := range report.Entries {
+		decisions = append(decisions, reportEntry.Decision)
+	}
+
+	if _e, _a := "selected,evicted,excluded,pinned", strings.Join(decisions, ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			if err := report.Encode(buf, format); err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			var document interface{}
+
+			if format == "json" {
+				err = json.Unmarshal(buf.Bytes(), &document)
+			} else {
+				err = yaml.Unmarshal(buf.Bytes(), &document)
+			}
+
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			} else if err := validateReportSchema(schema, document, ""); err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			document.(map[string]interface{})["entries"].([]interface{})[0].(map[string]interface{})["decision"] = "unknown"
+
+			if err := validateReportSchema(schema, document, ""); err == nil {
+				t.Fatalf("expected error but got: %v", err)
+			}
+		})
+	}
+}
+
+func mustParseRFC3339(v string) time.Time {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// validateReportSchema validates a document against the subset of JSON Schema
+// used by the report schema.
+func validateReportSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if v, ok := value.(time.Time); ok {
+		// yaml decodes timestamps
+		value = v.Format(time.RFC3339Nano)
+	}
+
+	if expected, ok := schema["const"]; ok && !reflect.DeepEqual(expected, value) {
+		return fmt.Errorf("%s: expected const %v", path, expected)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		var found bool
+
+		for _, expected := range enum {
+			found = found || reflect.DeepEqual(expected, value)
+		}
+
+		if !found {
+			return fmt.Errorf("%s: expected one of %v", path, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+
+		for _, required := range schema["required"].([]interface{}) {
+			if _, ok := object[required.(string)]; !ok {
+				return fmt.Errorf("%s: missing %s", path, required)
+			}
+		}
+
+		var keys []string
+
+		for key := range object {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			propertySchema, ok := properties[key]
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: unexpected property %s", path, key)
+				}
+
+				continue
+			}
+
+			if err := validateReportSchema(propertySchema.(map[string]interface{}), object[key], path+"/"+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+
+		for itemIdx, item := range array {
+			if err := validateReportSchema(schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s/%d", path, itemIdx)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string", path)
+		} else if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: expected date-time: %v", path, err)
+			}
+		}
+	case "integer":
+		var number float64
+
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case int:
+			number = float64(v)
+		default:
+			return fmt.Errorf("%s: expected integer", path)
+		}
+
+		if number != float64(int64(number)) {
+			return fmt.Errorf("%s: expected integer", path)
+		} else if minimum, ok := schema["minimum"].(float64); ok && number < minimum {
+			return fmt.Errorf("%s: expected at least %v", path, minimum)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package rootcmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dpb587/timepolicy"
+)
+
+type stats struct {
+	EntriesRead        int
+	EntriesSelected    int
+	EntriesPinned      int
+	EntriesExcluded    int
+	EntriesEvicted     int
+	EntriesUnparseable int
+
+	Policies []policyStats
+}
+
+type policyStats struct {
+	Name string
+	timepolicy.PolicySelectionStats
+}
+
+func newStats(entriesRead, entriesUnparseable int, policySelections *timepolicy.PolicySelectionSet) *stats {
+	// pinned entries are never evaluated by policies, so they are counted apart
+	entriesPinned := len(policySelections.Pinned())
+	entriesSelected := len(policySelections.Entries()) - entriesPinned
+
+	s := &stats{
+		EntriesRead:        entriesRead,
+		EntriesSelected:    entriesSelected,
+		EntriesPinned:      entriesPinned,
+		EntriesExcluded:    policySelections.Excluded(),
+		EntriesEvicted:     entriesRead - entriesSelected - entriesPinned - policySelections.Excluded(),
+		EntriesUnparseable: entriesUnparseable,
+	}
+
+	for _, policySelection := range policySelections.Selections() {
+		s.Policies = append(s.Policies, policyStats{
+			Name:                 policySelection.Spec().Name(),
+			PolicySelectionStats: policySelection.Stats(),
+		})
+	}
+
+	return s
+}
+
+func (s *stats) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+
+	n, err := fmt.Fprintf(
+		w,
+		"entries: read=%d selected=%d pinned=%d excluded=%d evicted=%d unparseable=%d\n",
+		s.EntriesRead,
+		s.EntriesSelected,
+		s.EntriesPinned,
+		s.EntriesExcluded,
+		s.EntriesEvicted,
+		s.EntriesUnparseable,
+	)
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	for _, policy := range s.Policies {
+		n, err := fmt.Fprintf(
+			w,
+			"%s: in-range=%d buckets=%d claimed=%d\n",
+			policy.Name,
+			policy.Matched,
+			policy.Buckets,
+			policy.Selected,
+		)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
@@ -0,0 +1,62 @@
+package rootcmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpb587/timepolicy"
+)
+
+func TestStats(t *testing.T) {
+	timepolicy.Now = func() time.Time {
+		return time.Date(2023, 1, 1, 1, 2, 3, 0, time.UTC)
+	}
+
+	daily, err := timepolicy.ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	pss := timepolicy.NewPolicySelectionSet([]*timepolicy.PolicySpec{daily.Resolve(timepolicy.Now())}, timepolicy.NewDiscardEntryWriter())
+	pss.Pin(func(e *timepolicy.Entry) (bool, error) {
+		return e.Fields[1] == "held", nil
+	})
+	pss.Exclude(func(e *timepolicy.Entry) (bool, error) {
+		return e.Fields[1] == "failed", nil
+	})
+
+	raws := []string{
+		"2022-12-31T18:00:00Z ok",
+		"2022-12-31T06:00:00Z ok",
+		"2022-12-30T06:00:00Z failed",
+		"2022-12-29T06:00:00Z held",
+		"2022-01-01T06:00:00Z ok",
+	}
+
+	for _, raw := range raws {
+		fields := strings.Fields(raw)
+
+		entryTime, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		if _, err := pss.EvaluateEntry(&timepolicy.Entry{Raw: raw, Fields: fields, Time: entryTime}); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if err := pss.Flush(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if _, err := newStats(len(raws), 0, pss).WriteTo(buf); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "entries: read=5 selected=1 pinned=1 excluded=1 evicted=2 unparseable=0\ndaily: in-range=2 buckets=1 claimed=1\n", buf.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
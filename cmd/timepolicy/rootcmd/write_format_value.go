@@ -3,17 +3,15 @@ package rootcmd
 import (
 	"errors"
 	"fmt"
-	"io"
 	"strconv"
 	"strings"
 
 	"github.com/alecthomas/kong"
-	"github.com/dpb587/timepolicy"
 	"github.com/dpb587/timepolicy/internal"
 )
 
 type WriteFormatValue struct {
-	builder func(w io.Writer) timepolicy.EntryWriter
+	fields []int
 }
 
 var _ kong.MapperValue = &WriteFormatValue{}
@@ -26,20 +24,29 @@ func (v *WriteFormatValue) Decode(ctx *kong.DecodeContext) error {
 		return err
 	}
 
-	if internal.DollarFieldRegExp.MatchString(raw) {
-		fieldColumn, err := strconv.ParseInt(strings.TrimPrefix(raw, "$"), 10, 64)
+	var fields []int
+
+	for _, rawField := range strings.Split(raw, ",") {
+		if !internal.DollarFieldRegExp.MatchString(rawField) {
+			return errors.New("unsupported value")
+		}
+
+		fieldColumn, err := strconv.ParseInt(strings.TrimPrefix(rawField, "$"), 10, 64)
 		if err != nil {
 			return fmt.Errorf("parsing field number: %v", err)
 		} else if fieldColumn < 1 {
 			return errors.New("expected field number to be greater than 0")
 		}
 
-		v.builder = func(w io.Writer) timepolicy.EntryWriter {
-			return timepolicy.NewEntryFieldWriter(w, int(fieldColumn-1))
-		}
-
-		return nil
+		fields = append(fields, int(fieldColumn-1))
 	}
 
-	return errors.New("unsupported value")
+	v.fields = fields
+
+	return nil
+}
+
+// Fields returns the indices of the fields to write, if any.
+func (v *WriteFormatValue) Fields() []int {
+	return v.fields
 }
@@ -0,0 +1,114 @@
+package schedulecmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/rootcmd"
+)
+
+type Command struct {
+	rootcmd.InputOptions  `embed:""`
+	rootcmd.PolicyOptions `embed:""`
+
+	Write       io.Writer                 `name:"write-to" short:"o" placeholder:"PATH" type:"path" help:"Write selected entries to file or path. Default is stdout."`
+	WriteFormat *rootcmd.WriteFormatValue `name:"write" placeholder:"FORMAT" help:"Write selected entries in a custom format. Fields may be output using dollar + field number, such as $1 for the first field."`
+}
+
+func (cmd *Command) BeforeApply() error {
+	cmd.InputOptions.SetDefaults()
+	cmd.Write = os.Stdout
+	cmd.WriteFormat = &rootcmd.WriteFormatValue{}
+
+	return nil
+}
+
+func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	var reference = timepolicy.Now()
+
+	policies, err := cmd.PolicySpecs(reference)
+	if err != nil {
+		return err
+	}
+
+	// excluded entries are never selected, so they are not projected
+	exclusions := timepolicy.NewPolicySelectionSet(nil, timepolicy.NewDiscardEntryWriter())
+
+	err = cmd.ApplyExcludes(exclusions, reference)
+	if err != nil {
+		return err
+	}
+
+	var entries []*timepolicy.Entry
+
+	_, err = cmd.ReadEntries(func(offset int, entry *timepolicy.Entry) error {
+		if _, err := exclusions.EvaluateEntry(entry); err != nil {
+			return fmt.Errorf("processing entry %d: %v", offset, err)
+		} else if exclusions.IsExcluded(entry) {
+			return nil
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	expiry, err := timepolicy.ProjectEntryExpiry(policies, entries, reference, cmd.Exclusive)
+	if err != nil {
+		return fmt.Errorf("projecting expiry: %v", err)
+	}
+
+	var selected []*timepolicy.Entry
+
+	for _, entry := range entries {
+		if _, known := expiry[entry]; known {
+			selected = append(selected, entry)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		iExpiry, jExpiry := expiry[selected[i]], expiry[selected[j]]
+
+		if jExpiry.IsZero() {
+			return !iExpiry.IsZero()
+		} else if iExpiry.IsZero() {
+			return false
+		}
+
+		return iExpiry.Before(jExpiry)
+	})
+
+	if appOptions.Quiet {
+		return nil
+	}
+
+	w := cmd.NewEntryWriter(cmd.Write, cmd.WriteFormat.Fields(), func(e *timepolicy.Entry) ([]string, error) {
+		if expiry[e].IsZero() {
+			return []string{"never"}, nil
+		}
+
+		return []string{expiry[e].Format(time.RFC3339)}, nil
+	})
+
+	for _, entry := range selected {
+		err := w.WriteEntry(entry)
+		if err != nil {
+			return fmt.Errorf("writing schedule: %v", err)
+		}
+	}
+
+	if w.EntriesWritten() == 0 {
+		return rootcmd.ErrNoEntries
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package simulatecmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/dpb587/timepolicy"
+	"github.com/dpb587/timepolicy/cmd/cmdutil"
+	"github.com/dpb587/timepolicy/cmd/timepolicy/rootcmd"
+)
+
+var ageDistribution = []struct {
+	label string
+	age   time.Duration
+}{
+	{"0-1d", 24 * time.Hour},
+	{"1d-7d", 7 * 24 * time.Hour},
+	{"7d-30d", 30 * 24 * time.Hour},
+	{"30d-90d", 90 * 24 * time.Hour},
+	{"90d-1y", 365 * 24 * time.Hour},
+	{"1y+", 0},
+}
+
+type Command struct {
+	rootcmd.PolicyOptions `embed:""`
+
+	Every    rootcmd.DurationValue `name:"every" placeholder:"DURATION" required:"" help:"Interval between generated entries, such as 1h or 1d."`
+	Duration *rootcmd.RangeValue   `name:"duration" placeholder:"RANGE" required:"" help:"Length of the simulation in the format of a policy Time Range, such as 2y."`
+}
+
+func (cmd *Command) Run(app *kong.Kong, appOptions *cmdutil.AppOptions) error {
+	every := time.Duration(cmd.Every)
+	if every <= 0 {
+		return errors.New("every: expected duration to be greater than 0")
+	}
+
+	end := timepolicy.Now()
+	start := cmd.Duration.Start(end)
+
+	policies, err := cmd.PolicySpecs(end)
+	if err != nil {
+		return err
+	}
+
+	var retained []*timepolicy.Entry
+	var retainedPeak, steps int
+
+	for clock := start; !clock.After(end); clock = clock.Add(every) {
+		steps++
+
+		policySelections := cmd.NewPolicySelectionSet(resolvePolicies(policies, clock), timepolicy.NewDiscardEntryWriter())
+
+		err := cmd.ApplyExcludes(policySelections, clock)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range append(retained, newEntry(clock)) {
+			_, err := policySelections.EvaluateEntry(entry)
+			if err != nil {
+				return fmt.Errorf("evaluating %s: %v", clock.Format(time.RFC3339), err)
+			}
+		}
+
+		err = policySelections.Flush()
+		if err != nil {
+			return fmt.Errorf("evaluating %s: %v", clock.Format(time.RFC3339), err)
+		}
+
+		retained = policySelections.Entries()
+
+		if len(retained) > retainedPeak {
+			retainedPeak = len(retained)
+		}
+	}
+
+	if appOptions.Quiet {
+		return nil
+	}
+
+	writeSummary(app.Stdout, end, steps, retained, retainedPeak)
+
+	return nil
+}
+
+func newEntry(t time.Time) *timepolicy.Entry {
+	raw := t.Format(time.RFC3339)
+
+	return &timepolicy.Entry{
+		Raw:    raw,
+		Fields: []string{raw},
+		Time:   t,
+	}
+}
+
+func resolvePolicies(policies []*timepolicy.PolicySpec, reference time.Time) []*timepolicy.PolicySpec {
+	var resolved []*timepolicy.PolicySpec
+
+	for _, policy := range policies {
+		resolved = append(resolved, policy.Resolve(reference))
+	}
+
+	return resolved
+}
+
+func writeSummary(w io.Writer, end time.Time, steps int, retained []*timepolicy.Entry, retainedPeak int) {
+	sort.Slice(retained, func(i, j int) bool {
+		return retained[i].Time.After(retained[j].Time)
+	})
+
+	fmt.Fprintf(w, "entries: generated=%d retained=%d peak=%d\n", steps, len(retained), retainedPeak)
+
+	var maxGap time.Duration
+	var maxGapNewer, maxGapOlder *timepolicy.Entry
+
+	for entryIdx := 1; entryIdx < len(retained); entryIdx++ {
+		gap := retained[entryIdx-1].Time.Sub(retained[entryIdx].Time)
+		if gap > maxGap {
+			maxGap = gap
+			maxGapNewer = retained[entryIdx-1]
+			maxGapOlder = retained[entryIdx]
+		}
+	}
+
+	if maxGapNewer != nil {
+		fmt.Fprintf(w, "max-gap: %s between %s and %s\n", maxGap, maxGapOlder.Raw, maxGapNewer.Raw)
+	}
+
+	distribution := make([]int, len(ageDistribution))
+
+	for _, entry := range retained {
+		age := end.Sub(entry.Time)
+		ageIdx := sort.Search(len(ageDistribution)-1, func(i int) bool {
+			return age < ageDistribution[i].age
+		})
+
+		distribution[ageIdx]++
+	}
+
+	for ageIdx, age := range ageDistribution {
+		fmt.Fprintf(w, "age %s: %d\n", age.label, distribution[ageIdx])
+	}
+}
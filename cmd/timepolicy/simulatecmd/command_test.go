@@ -0,0 +1,89 @@
+package simulatecmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dpb587/timepolicy"
+)
+
+func TestWriteSummary(t *testing.T) {
+	end := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		ages     []time.Duration
+		peak     int
+		expected string
+	}{
+		{
+			name: "empty",
+			expected: `entries: generated=10 retained=0 peak=0
+age 0-1d: 0
+age 1d-7d: 0
+age 7d-30d: 0
+age 30d-90d: 0
+age 90d-1y: 0
+age 1y+: 0
+`,
+		},
+		{
+			name: "single",
+			ages: []time.Duration{2 * time.Hour},
+			peak: 1,
+			expected: `entries: generated=10 retained=1 peak=1
+age 0-1d: 1
+age 1d-7d: 0
+age 7d-30d: 0
+age 30d-90d: 0
+age 90d-1y: 0
+age 1y+: 0
+`,
+		},
+		{
+			name: "gap",
+			ages: []time.Duration{48 * time.Hour, time.Hour, 24 * time.Hour, 10 * 24 * time.Hour},
+			peak: 6,
+			expected: `entries: generated=10 retained=4 peak=6
+max-gap: 192h0m0s between 2022-12-22T00:00:00Z and 2022-12-30T00:00:00Z
+age 0-1d: 1
+age 1d-7d: 2
+age 7d-30d: 1
+age 30d-90d: 0
+age 90d-1y: 0
+age 1y+: 0
+`,
+		},
+		{
+			name: "overflow",
+			ages: []time.Duration{364 * 24 * time.Hour, 365 * 24 * time.Hour, 1000 * 24 * time.Hour},
+			peak: 3,
+			expected: `entries: generated=10 retained=3 peak=3
+max-gap: 15240h0m0s between 2020-04-06T00:00:00Z and 2022-01-01T00:00:00Z
+age 0-1d: 0
+age 1d-7d: 0
+age 7d-30d: 0
+age 30d-90d: 0
+age 90d-1y: 1
+age 1y+: 2
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var retained []*timepolicy.Entry
+
+			for _, age := range tc.ages {
+				entryTime := end.Add(-age)
+				retained = append(retained, &timepolicy.Entry{Raw: entryTime.Format(time.RFC3339), Time: entryTime})
+			}
+
+			buf := bytes.NewBuffer(nil)
+			writeSummary(buf, end, 10, retained, tc.peak)
+
+			if _e, _a := tc.expected, buf.String(); _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			}
+		})
+	}
+}
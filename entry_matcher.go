@@ -0,0 +1,71 @@
+package timepolicy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dpb587/timepolicy/internal"
+	"github.com/google/cel-go/cel"
+)
+
+type EntryMatcherFunc func(e *Entry) (bool, error)
+
+// NewExpressionEntryMatcher returns a matcher for entries where the expression
+// evaluates to true. Expressions have the same context as the if qualifier.
+func NewExpressionEntryMatcher(expr string) (EntryMatcherFunc, error) {
+	ast, issues := internal.InputExpressionEnv.Compile(expr)
+	if err := issues.Err(); err != nil {
+		return nil, fmt.Errorf("compiling: %v", err)
+	} else if !ast.IsChecked() || ast.OutputType() != cel.BoolType {
+		return nil, errors.New("expression must have boolean result")
+	}
+
+	prg, err := internal.InputExpressionEnv.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("installing: %v", err)
+	}
+
+	return func(e *Entry) (bool, error) {
+		val, _, err := e.Eval(prg)
+		if err != nil {
+			return false, fmt.Errorf("evaluating expression: %v", err)
+		}
+
+		return val.Value().(bool), nil
+	}, nil
+}
+
+// NewKeyEntryMatcher returns a matcher for entries where the raw entry or any of
+// its fields is equal to one of the keys.
+func NewKeyEntryMatcher(keys []string) EntryMatcherFunc {
+	keySet := map[string]struct{}{}
+
+	for _, key := range keys {
+		keySet[key] = struct{}{}
+	}
+
+	return func(e *Entry) (bool, error) {
+		if _, known := keySet[e.Raw]; known {
+			return true, nil
+		}
+
+		for _, field := range e.Fields {
+			if _, known := keySet[field]; known {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+}
+
+// NewExclusionEntryMatcher returns a matcher for entries within the range of the
+// policy which meet its condition. Only range, if, and name may be configured
+// since exclusions do not select entries.
+func NewExclusionEntryMatcher(spec *PolicySpec) (EntryMatcherFunc, error) {
+	if spec.bucket != nil || spec.oldest || spec.edges || spec.max != -1 || spec.prefer != nil || spec.spacing > 0 {
+		return nil, errors.New("exclusions only support a range, if, and name")
+	}
+
+	return spec.MatchEntry, nil
+}
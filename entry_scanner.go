@@ -1,6 +1,8 @@
 package timepolicy
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"time"
 )
@@ -12,6 +14,27 @@ type EntryScanner interface {
 	EntryOffset() int
 }
 
+// EntryParseError describes an entry which could not be parsed. Unlike other
+// errors, scanning may continue with the next entry by calling Scan again.
+type EntryParseError struct {
+	Offset int
+	Err    error
+}
+
+func (err *EntryParseError) Error() string {
+	return fmt.Sprintf("parsing entry %d: %v", err.Offset, err.Err)
+}
+
+func (err *EntryParseError) Unwrap() error {
+	return err.Err
+}
+
+func isEntryParseError(err error) bool {
+	var parseErr *EntryParseError
+
+	return errors.As(err, &parseErr)
+}
+
 type EntryFieldSplitterFunc func(v string, limit int) ([]string, error)
 
 //
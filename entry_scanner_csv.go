@@ -11,6 +11,7 @@ import (
 
 type csvEntryScanner struct {
 	r          *csv.Reader
+	rr         *csvRecordReader
 	timeField  int
 	timeParser TimeParserFunc
 
@@ -21,6 +22,9 @@ type csvEntryScanner struct {
 
 var _ EntryScanner = &csvEntryScanner{}
 
+// NewCSVEntryScanner scans entries from a csv.Reader. The raw value of entries
+// is re-encoded from their fields; see NewCSVRecordEntryScanner to preserve the
+// original input.
 func NewCSVEntryScanner(r *csv.Reader, timeField int, timeParser TimeParserFunc) EntryScanner {
 	return &csvEntryScanner{
 		r:          r,
@@ -29,9 +33,35 @@ func NewCSVEntryScanner(r *csv.Reader, timeField int, timeParser TimeParserFunc)
 	}
 }
 
+// NewCSVRecordEntryScanner scans entries from CSV input, using the original input
+// of each record as the raw value of entries. The optional readerApplier may
+// configure the csv.Reader, such as its separator.
+func NewCSVRecordEntryScanner(r io.Reader, readerApplier func(r *csv.Reader), timeField int, timeParser TimeParserFunc) EntryScanner {
+	rr := &csvRecordReader{
+		r: r,
+	}
+
+	cr := csv.NewReader(rr)
+
+	if readerApplier != nil {
+		readerApplier(cr)
+	}
+
+	return &csvEntryScanner{
+		r:          cr,
+		rr:         rr,
+		timeField:  timeField,
+		timeParser: timeParser,
+	}
+}
+
 func (es *csvEntryScanner) Scan() bool {
 	if es.err != nil {
-		return false
+		if !isEntryParseError(es.err) {
+			return false
+		}
+
+		es.err = nil
 	}
 
 	fields, err := es.r.Read()
@@ -40,6 +70,24 @@ func (es *csvEntryScanner) Scan() bool {
 			return false
 		}
 
+		var csvErr *csv.ParseError
+
+		if errors.As(err, &csvErr) {
+			// the reader resumes with the following record
+			es.entryOffset++
+
+			if es.rr != nil {
+				es.rr.Record(es.r.InputOffset())
+			}
+
+			es.err = &EntryParseError{
+				Offset: es.entryOffset,
+				Err:    csvErr.Err,
+			}
+
+			return false
+		}
+
 		es.err = err
 
 		return false
@@ -47,27 +95,40 @@ func (es *csvEntryScanner) Scan() bool {
 
 	es.entryOffset++
 
+	var raw string
+
+	if es.rr != nil {
+		raw = es.rr.Record(es.r.InputOffset())
+	} else {
+		buf := bytes.NewBuffer(nil)
+		w := csv.NewWriter(buf)
+		w.Write(fields)
+		w.Flush()
+
+		raw = strings.TrimSuffix(buf.String(), "\n")
+	}
+
 	if len(fields)-1 < es.timeField {
-		es.err = fmt.Errorf("parsing entry %d: parsing time: missing field %d", es.entryOffset, es.timeField)
+		es.err = &EntryParseError{
+			Offset: es.entryOffset,
+			Err:    fmt.Errorf("parsing time: missing field %d", es.timeField),
+		}
 
 		return false
 	}
 
 	timeParsed, err := es.timeParser(fields[es.timeField])
 	if err != nil {
-		es.err = fmt.Errorf("parsing entry %d: parsing time: %v", es.entryOffset, err)
+		es.err = &EntryParseError{
+			Offset: es.entryOffset,
+			Err:    fmt.Errorf("parsing time: %v", err),
+		}
 
 		return false
 	}
 
-	// hacky
-	raw := bytes.NewBuffer(nil)
-	w := csv.NewWriter(raw)
-	w.Write(fields)
-	w.Flush()
-
 	es.entry = &Entry{
-		Raw:    strings.TrimSuffix(raw.String(), "\n"),
+		Raw:    raw,
 		Fields: fields,
 		Time:   timeParsed,
 	}
@@ -86,3 +147,33 @@ func (es *csvEntryScanner) EntryOffset() int {
 func (es *csvEntryScanner) Err() error {
 	return es.err
 }
+
+//
+
+// csvRecordReader retains the bytes read by a csv.Reader so the original input
+// of a record can be recovered once the reader reports its offset.
+type csvRecordReader struct {
+	r io.Reader
+
+	buf       []byte
+	bufOffset int64
+}
+
+func (rr *csvRecordReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[0:n]...)
+
+	return n, err
+}
+
+// Record returns the input preceding offset which has not yet been returned,
+// excluding surrounding line breaks from skipped lines and the record itself.
+func (rr *csvRecordReader) Record(offset int64) string {
+	recordLen := int(offset - rr.bufOffset)
+	record := strings.Trim(string(rr.buf[0:recordLen]), "\r\n")
+
+	rr.buf = append(rr.buf[0:0], rr.buf[recordLen:]...)
+	rr.bufOffset = offset
+
+	return record
+}
@@ -31,7 +31,11 @@ func NewGenericEntryScanner(s *bufio.Scanner, fieldSplitter EntryFieldSplitterFu
 
 func (es *genericEntryScanner) Scan() bool {
 	if es.err != nil {
-		return false
+		if !isEntryParseError(es.err) {
+			return false
+		}
+
+		es.err = nil
 	}
 
 	scanned := es.s.Scan()
@@ -46,20 +50,29 @@ func (es *genericEntryScanner) Scan() bool {
 	raw := string(es.s.Bytes())
 	fields, err := es.fieldSplitter(raw, es.fieldsLimit)
 	if err != nil {
-		es.err = fmt.Errorf("parsing entry %d: splitting fields: %v", es.entryOffset, err)
+		es.err = &EntryParseError{
+			Offset: es.entryOffset,
+			Err:    fmt.Errorf("splitting fields: %v", err),
+		}
 
 		return false
 	}
 
 	if len(fields)-1 < es.timeField {
-		es.err = fmt.Errorf("parsing entry %d: parsing time: missing field %d", es.entryOffset, es.timeField)
+		es.err = &EntryParseError{
+			Offset: es.entryOffset,
+			Err:    fmt.Errorf("parsing time: missing field %d", es.timeField),
+		}
 
 		return false
 	}
 
 	timeParsed, err := es.timeParser(fields[es.timeField])
 	if err != nil {
-		es.err = fmt.Errorf("parsing entry %d: parsing time: %v", es.entryOffset, err)
+		es.err = &EntryParseError{
+			Offset: es.entryOffset,
+			Err:    fmt.Errorf("parsing time: %v", err),
+		}
 
 		return false
 	}
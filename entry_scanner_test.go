@@ -0,0 +1,84 @@
+package timepolicy
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGenericEntryScannerParseError(t *testing.T) {
+	es := NewGenericEntryScanner(
+		bufio.NewScanner(strings.NewReader("2022-12-31T18:00:00Z a\ninvalid b\n2022-12-31T19:00:00Z c\n")),
+		SpacesEntryFieldSplitter,
+		-1,
+		0,
+		parseRFC3339,
+	)
+
+	if _e, _a := true, es.Scan(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := false, es.Scan(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	var parseErr *EntryParseError
+
+	if _e, _a := true, errors.As(es.Err(), &parseErr); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 2, parseErr.Offset; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	if _e, _a := true, es.Scan(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "2022-12-31T19:00:00Z c", es.Entry().Raw; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 2, es.EntryOffset(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := false, es.Scan(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if err := es.Err(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+}
+
+func TestCSVEntryScannerParseError(t *testing.T) {
+	es := NewCSVRecordEntryScanner(
+		strings.NewReader("2022-12-31T18:00:00Z,a\n2022-12-31T\"19:00:00Z,b\n2022-12-31T20:00:00Z,c,extra\n2022-12-31T21:00:00Z,d\n"),
+		func(r *csv.Reader) {
+			r.FieldsPerRecord = 2
+		},
+		0,
+		parseRFC3339,
+	)
+
+	var raws []string
+	var offsets []int
+
+	for {
+		if es.Scan() {
+			raws = append(raws, es.Entry().Raw)
+
+			continue
+		}
+
+		var parseErr *EntryParseError
+
+		if !errors.As(es.Err(), &parseErr) {
+			break
+		}
+
+		offsets = append(offsets, parseErr.Offset)
+	}
+
+	if err := es.Err(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "2022-12-31T18:00:00Z,a|2022-12-31T21:00:00Z,d", strings.Join(raws, "|"); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "[2 3]", fmt.Sprintf("%v", offsets); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
@@ -1,12 +1,18 @@
 package timepolicy
 
-import "io"
+import (
+	"io"
+	"strings"
+)
 
 type EntryWriter interface {
 	EntriesWritten() int64
 	WriteEntry(e *Entry) error
 }
 
+// EntryColumnsFunc returns additional columns to be written alongside an entry.
+type EntryColumnsFunc func(e *Entry) ([]string, error)
+
 //
 
 type rawEntryWriter struct {
@@ -34,15 +40,29 @@ func (w *rawEntryWriter) WriteEntry(e *Entry) error {
 //
 
 type builtinEntryFieldWriter struct {
-	c     int64
-	w     io.Writer
-	field int
+	c       int64
+	w       io.Writer
+	fields  []int
+	columns EntryColumnsFunc
 }
 
-func NewEntryFieldWriter(w io.Writer, field int) EntryWriter {
+// NewEntryFieldWriter writes the fields at the given indices of each entry,
+// separated by a space.
+func NewEntryFieldWriter(w io.Writer, fields ...int) EntryWriter {
 	return &builtinEntryFieldWriter{
-		w:     w,
-		field: field,
+		w:      w,
+		fields: fields,
+	}
+}
+
+// NewEntryColumnsWriter writes the fields at the given indices of each entry,
+// or the raw entry if there are none, followed by any columns. Values are
+// separated by a space.
+func NewEntryColumnsWriter(w io.Writer, fields []int, columns EntryColumnsFunc) EntryWriter {
+	return &builtinEntryFieldWriter{
+		w:       w,
+		fields:  fields,
+		columns: columns,
 	}
 }
 
@@ -51,16 +71,31 @@ func (w *builtinEntryFieldWriter) EntriesWritten() int64 {
 }
 
 func (w *builtinEntryFieldWriter) WriteEntry(e *Entry) error {
-	var err error
+	var values = make([]string, len(w.fields))
 
-	w.c++
+	for fieldIdx, field := range w.fields {
+		if field < len(e.Fields) {
+			values[fieldIdx] = e.Fields[field]
+		}
+	}
 
-	if len(e.Fields) < w.field {
-		_, err = w.w.Write([]byte("\n"))
-	} else {
-		_, err = w.w.Write([]byte(e.Fields[w.field] + "\n"))
+	if len(w.fields) == 0 {
+		values = append(values, e.Raw)
 	}
 
+	if w.columns != nil {
+		columns, err := w.columns(e)
+		if err != nil {
+			return err
+		}
+
+		values = append(values, columns...)
+	}
+
+	w.c++
+
+	_, err := w.w.Write([]byte(strings.Join(values, " ") + "\n"))
+
 	return err
 }
 
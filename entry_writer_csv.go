@@ -0,0 +1,96 @@
+package timepolicy
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+type csvEntryWriter struct {
+	c       int64
+	w       io.Writer
+	comma   rune
+	fields  []int
+	columns EntryColumnsFunc
+
+	buf *bytes.Buffer
+	cw  *csv.Writer
+}
+
+// NewCSVEntryWriter writes entries as records separated by comma. When fields
+// is empty, the original record is written as it was read; otherwise only the
+// fields at the given indices are written. Any columns are appended to the end
+// of each record.
+func NewCSVEntryWriter(w io.Writer, comma rune, fields []int, columns EntryColumnsFunc) EntryWriter {
+	buf := bytes.NewBuffer(nil)
+	cw := csv.NewWriter(buf)
+	cw.Comma = comma
+
+	return &csvEntryWriter{
+		w:       w,
+		comma:   comma,
+		fields:  fields,
+		columns: columns,
+		buf:     buf,
+		cw:      cw,
+	}
+}
+
+func (w *csvEntryWriter) EntriesWritten() int64 {
+	return w.c
+}
+
+func (w *csvEntryWriter) WriteEntry(e *Entry) error {
+	var record []string
+
+	if len(w.fields) > 0 {
+		for _, field := range w.fields {
+			if field < len(e.Fields) {
+				record = append(record, e.Fields[field])
+			} else {
+				record = append(record, "")
+			}
+		}
+	}
+
+	if w.columns != nil {
+		columns, err := w.columns(e)
+		if err != nil {
+			return err
+		}
+
+		record = append(record, columns...)
+	}
+
+	w.c++
+	w.buf.Reset()
+
+	if len(w.fields) == 0 {
+		w.buf.WriteString(e.Raw)
+
+		if len(record) == 0 {
+			w.buf.WriteByte('\n')
+
+			_, err := w.w.Write(w.buf.Bytes())
+
+			return err
+		}
+
+		w.buf.WriteRune(w.comma)
+	}
+
+	err := w.cw.Write(record)
+	if err != nil {
+		return err
+	}
+
+	w.cw.Flush()
+
+	if err := w.cw.Error(); err != nil {
+		return err
+	}
+
+	_, err = w.w.Write(w.buf.Bytes())
+
+	return err
+}
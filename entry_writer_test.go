@@ -0,0 +1,86 @@
+package timepolicy
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestCSVEntryWriter(t *testing.T) {
+	input := "2022-12-31T18:00:00Z,\"backup, full\",  1024\r\n\n2022-12-31T19:00:00Z,\"backup \"\"partial\"\"\",512\n"
+
+	es := NewCSVRecordEntryScanner(strings.NewReader(input), nil, 0, parseRFC3339)
+
+	var entries []*Entry
+
+	for es.Scan() {
+		entries = append(entries, es.Entry())
+	}
+
+	if err := es.Err(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := 2, len(entries); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := `2022-12-31T18:00:00Z,"backup, full",  1024`, entries[0].Raw; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := `2022-12-31T19:00:00Z,"backup ""partial""",512`, entries[1].Raw; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	{ // raw; columns
+		buf := bytes.NewBuffer(nil)
+		w := NewCSVEntryWriter(buf, ',', nil, func(e *Entry) ([]string, error) {
+			return []string{"policy-0, policy-1"}, nil
+		})
+
+		for _, e := range entries {
+			if err := w.WriteEntry(e); err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+		}
+
+		if _e, _a := "2022-12-31T18:00:00Z,\"backup, full\",  1024,\"policy-0, policy-1\"\n2022-12-31T19:00:00Z,\"backup \"\"partial\"\"\",512,\"policy-0, policy-1\"\n", buf.String(); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		} else if _e, _a := int64(2), w.EntriesWritten(); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+
+	{ // fields
+		buf := bytes.NewBuffer(nil)
+		w := NewCSVEntryWriter(buf, '\t', []int{1, 3}, nil)
+
+		if err := w.WriteEntry(entries[1]); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		if _e, _a := "\"backup \"\"partial\"\"\"\t\n", buf.String(); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+}
+
+func TestCSVEntryScannerReaderApplier(t *testing.T) {
+	es := NewCSVRecordEntryScanner(strings.NewReader("a\t2022-12-31T18:00:00Z\n"), func(r *csv.Reader) {
+		r.Comma = '\t'
+	}, 1, parseRFC3339)
+
+	if _e, _a := true, es.Scan(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "a\t2022-12-31T18:00:00Z", es.Entry().Raw; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 2, len(es.Entry().Fields); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
+
+func TestCSVEntryScannerReader(t *testing.T) {
+	es := NewCSVEntryScanner(csv.NewReader(strings.NewReader("2022-12-31T18:00:00Z,\"a\"\n")), 0, parseRFC3339)
+
+	if _e, _a := true, es.Scan(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "2022-12-31T18:00:00Z,a", es.Entry().Raw; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/alecthomas/kong v0.7.1
 	github.com/google/cel-go v0.15.0
 	github.com/google/go-licenses v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -2,6 +2,8 @@ package internal
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/google/cel-go/cel"
 )
@@ -20,3 +22,46 @@ func init() {
 
 	InputExpressionEnv = env
 }
+
+// ExpressionReferences returns whether a checked expression refers to the named
+// variable.
+func ExpressionReferences(ast *cel.Ast, name string) bool {
+	checked, err := cel.AstToCheckedExpr(ast)
+	if err != nil {
+		return false
+	}
+
+	for _, ref := range checked.GetReferenceMap() {
+		if ref.GetName() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseDuration parses a duration such as time.ParseDuration, but additionally
+// supports whole days (d) and weeks (w) such as 7d.
+func ParseDuration(v string) (time.Duration, error) {
+	if len(v) > 1 {
+		var unit time.Duration
+
+		switch v[len(v)-1] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'w':
+			unit = 7 * 24 * time.Hour
+		}
+
+		if unit > 0 {
+			n, err := strconv.ParseInt(v[0:len(v)-1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("parsing number: %v", err)
+			}
+
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	return time.ParseDuration(v)
+}
@@ -0,0 +1,596 @@
+package timepolicy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InteropFormats lists the formats supported by ImportPolicies and
+// ExportPolicies.
+var InteropFormats = []string{"borg", "restic", "sanoid"}
+
+var interopBucketFlags = map[string]string{
+	"hourly":  "hour",
+	"daily":   "day",
+	"weekly":  "week",
+	"monthly": "month",
+	"yearly":  "year",
+}
+
+var interopBucketNames = map[string]string{
+	"hour":  "hourly",
+	"day":   "daily",
+	"week":  "weekly",
+	"month": "monthly",
+	"year":  "yearly",
+}
+
+var interopBucketRangeUnits = map[string]byte{
+	"hour":  'h',
+	"day":   'd',
+	"week":  'w',
+	"month": 'm',
+	"year":  'y',
+}
+
+// ImportPolicies translates the retention configuration of another tool into
+// lines of a policy file, along with any differences in behavior which the
+// policies cannot express. For borg and restic, input is the keep flags of prune
+// or forget (e.g. --keep-daily 7 --keep-weekly 4); for sanoid, input is the text
+// of a configuration file with template or dataset sections.
+func ImportPolicies(format string, input string) ([]string, []string, error) {
+	switch format {
+	case "borg":
+		return importKeepFlags(format, input)
+	case "restic":
+		return importKeepFlags(format, input)
+	case "sanoid":
+		lines, err := importSanoid(input)
+
+		return lines, nil, err
+	}
+
+	return nil, nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// ExportPolicies translates policies into the retention configuration of another
+// tool, along with any differences in behavior which the tool cannot express,
+// or returns an error for policies which cannot be expressed at all.
+func ExportPolicies(format string, specs []*PolicySpec) (string, []string, error) {
+	switch format {
+	case "borg", "restic":
+		return exportKeepFlags(format, specs)
+	case "sanoid":
+		return exportSanoid(specs)
+	}
+
+	return "", nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+const (
+	interopDifferenceResticRange = "restic ranges are relative to the newest snapshot rather than the current time, so fewer entries are kept once snapshots stop"
+	interopDifferenceBorgRules   = "borg skips periods whose newest archive was kept by an earlier rule without counting them, so later rules keep older archives than independent or --exclusive policies"
+	interopDifferenceFill        = "%s also keeps the oldest entry while a rule has fewer periods than its count; policies keep it only with fill"
+	interopDifferenceSanoidTypes = "sanoid prunes each snapshot type separately, keeping the newest snapshots of a type regardless of age; policies match it only with max and a range, each using if=entry.contains(\"_TYPE\")"
+)
+
+//
+
+var interopKeepShortFlags = map[string]map[string]string{
+	"borg": {
+		"-H": "--keep-hourly",
+		"-d": "--keep-daily",
+		"-w": "--keep-weekly",
+		"-m": "--keep-monthly",
+		"-y": "--keep-yearly",
+	},
+	"restic": {
+		"-l": "--keep-last",
+		"-H": "--keep-hourly",
+		"-d": "--keep-daily",
+		"-w": "--keep-weekly",
+		"-m": "--keep-monthly",
+		"-y": "--keep-yearly",
+	},
+}
+
+func importKeepFlags(format string, input string) ([]string, []string, error) {
+	var specs []string
+	var differences []string
+	var rangeRules int
+
+	args := strings.Fields(input)
+
+	for argIdx := 0; argIdx < len(args); argIdx++ {
+		flag, value, hasValue := strings.Cut(args[argIdx], "=")
+
+		if long, ok := interopKeepShortFlags[format][flag]; ok {
+			flag = long
+		}
+
+		if !hasValue {
+			if argIdx+1 == len(args) {
+				return nil, nil, fmt.Errorf("parsing %s: missing value", flag)
+			}
+
+			argIdx++
+			value = args[argIdx]
+		}
+
+		rule := strings.TrimPrefix(flag, "--keep-")
+		if rule == flag {
+			return nil, nil, fmt.Errorf("unsupported flag: %s", flag)
+		}
+
+		if strings.HasPrefix(rule, "within") {
+			rangeRaw, err := importKeepDuration(format, value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("parsing %s: %v", flag, err)
+			}
+
+			rangeRules++
+
+			if rule == "within" {
+				specs = append(specs, fmt.Sprintf("%s;name=within // %s %s", rangeRaw, flag, value))
+
+				continue
+			}
+
+			bucket, ok := interopBucketFlags[strings.TrimPrefix(rule, "within-")]
+			if !ok || format != "restic" {
+				return nil, nil, fmt.Errorf("unsupported flag: %s", flag)
+			}
+
+			// restic also keeps the oldest snapshot when it is within range
+			specs = append(specs, fmt.Sprintf("%s;by=%s;fill;name=%s // %s %s", rangeRaw, bucket, rule, flag, value))
+
+			continue
+		}
+
+		count, err := strconv.Atoi(value)
+		if err != nil || count == 0 || count < -1 {
+			return nil, nil, fmt.Errorf("parsing %s: expected a count greater than 0 or -1 for all", flag)
+		} else if count == -1 && format == "borg" {
+			return nil, nil, fmt.Errorf("parsing %s: expected a count greater than 0", flag)
+		}
+
+		var bucket string
+
+		switch rule {
+		case "last":
+			if format == "restic" {
+				specs = append(specs, fmt.Sprintf("max=%d;name=last // %s %s", count, flag, value))
+
+				continue
+			}
+
+			// borg treats last as secondly
+			bucket = "1s"
+		case "secondly":
+			bucket = "1s"
+		case "minutely":
+			bucket = "1m"
+		default:
+			var ok bool
+
+			bucket, ok = interopBucketFlags[rule]
+			if !ok {
+				return nil, nil, fmt.Errorf("unsupported flag: %s", flag)
+			}
+		}
+
+		if (bucket == "1s" || bucket == "1m") && format != "borg" {
+			return nil, nil, fmt.Errorf("unsupported flag: %s", flag)
+		}
+
+		if count == -1 {
+			specs = append(specs, fmt.Sprintf("by=%s;fill;name=%s // %s %s", bucket, rule, flag, value))
+		} else {
+			specs = append(specs, fmt.Sprintf("by=%s;last=%d;fill;name=%s // %s %s", bucket, count, rule, flag, value))
+		}
+	}
+
+	if len(specs) == 0 {
+		return nil, nil, errors.New("no keep flags found")
+	}
+
+	if format == "restic" && rangeRules > 0 {
+		differences = append(differences, interopDifferenceResticRange)
+	} else if format == "borg" && len(specs) > 1 {
+		differences = append(differences, interopDifferenceBorgRules)
+	}
+
+	return append([]string{fmt.Sprintf("# imported from %s: %s", format, strings.Join(args, " "))}, specs...), differences, nil
+}
+
+// importKeepDuration translates a duration of restic (e.g. 7d) or borg (e.g.
+// 12H) into a range. Borg months and years are always 31 and 365 days.
+func importKeepDuration(format string, value string) (string, error) {
+	if len(value) < 2 {
+		return "", fmt.Errorf("invalid duration: %s", value)
+	}
+
+	unit := value[len(value)-1]
+
+	number, err := strconv.ParseUint(value[0:len(value)-1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid duration: %s: only a single unit is supported", value)
+	}
+
+	switch {
+	case format == "borg" && unit == 'H':
+		unit = 'h'
+	case format == "borg" && unit == 'm':
+		number, unit = number*31, 'd'
+	case format == "borg" && unit == 'y':
+		number, unit = number*365, 'd'
+	case format == "borg" && strings.IndexByte("dw", unit) != -1:
+	case format == "restic" && strings.IndexByte("hdmy", unit) != -1:
+	default:
+		return "", fmt.Errorf("invalid duration: %s: unsupported unit", value)
+	}
+
+	return fmt.Sprintf("%d%c", number, unit), nil
+}
+
+func exportKeepFlags(format string, specs []*PolicySpec) (string, []string, error) {
+	var flags []string
+	var differences []string
+	var rangeRules, unfilledRules int
+
+	for _, spec := range specs {
+		if interopSpecHasBehavior(spec) {
+			return "", nil, fmt.Errorf("policy %s: only range, by, max, last, fill, and name can be exported", spec.name)
+		}
+
+		switch {
+		case spec.bucket == nil && spec.cutoffFunc == nil && (spec.max > 0 || format == "restic"):
+			if format == "borg" {
+				// borg keeps the newest by second, like by=1s;last=N;fill
+				unfilledRules++
+			}
+
+			flags = append(flags, fmt.Sprintf("--keep-last %d", spec.max))
+		case spec.bucket == nil && spec.cutoffFunc != nil && spec.max == -1:
+			within, err := exportKeepDuration(format, spec.rangeRaw)
+			if err != nil {
+				return "", nil, fmt.Errorf("policy %s: %v", spec.name, err)
+			}
+
+			rangeRules++
+			flags = append(flags, fmt.Sprintf("--keep-within %s", within))
+		case spec.bucket != nil && spec.max == 1 && format == "borg" && spec.cutoffFunc == nil && spec.last > 0 && (spec.bucketEnum == "1s" || spec.bucketEnum == "1m"):
+			rule := map[string]string{"1s": "last", "1m": "minutely"}[spec.bucketEnum]
+
+			if !spec.fill {
+				unfilledRules++
+			}
+
+			flags = append(flags, fmt.Sprintf("--keep-%s %d", rule, spec.last))
+		case spec.bucket != nil && spec.max == 1 && interopBucketNames[spec.bucketEnum] != "":
+			rule := interopBucketNames[spec.bucketEnum]
+
+			if spec.cutoffFunc != nil && spec.last == 0 && format == "restic" {
+				within, err := exportKeepDuration(format, spec.rangeRaw)
+				if err != nil {
+					return "", nil, fmt.Errorf("policy %s: %v", spec.name, err)
+				}
+
+				rangeRules++
+				flags = append(flags, fmt.Sprintf("--keep-within-%s %s", rule, within))
+			} else if spec.cutoffFunc == nil && spec.last > 0 {
+				flags = append(flags, fmt.Sprintf("--keep-%s %d", rule, spec.last))
+			} else if spec.cutoffFunc == nil && format == "restic" {
+				flags = append(flags, fmt.Sprintf("--keep-%s -1", rule))
+			} else {
+				return "", nil, fmt.Errorf("policy %s: cannot be expressed for %s", spec.name, format)
+			}
+
+			if !spec.fill {
+				unfilledRules++
+			}
+		default:
+			return "", nil, fmt.Errorf("policy %s: cannot be expressed for %s", spec.name, format)
+		}
+	}
+
+	if format == "restic" && rangeRules > 0 {
+		differences = append(differences, interopDifferenceResticRange)
+	} else if format == "borg" && len(flags) > 1 {
+		differences = append(differences, interopDifferenceBorgRules)
+	}
+
+	if unfilledRules > 0 {
+		differences = append(differences, fmt.Sprintf(interopDifferenceFill, format))
+	}
+
+	return strings.Join(flags, " "), differences, nil
+}
+
+func exportKeepDuration(format string, rangeRaw string) (string, error) {
+	number, err := strconv.ParseInt(rangeRaw[0:len(rangeRaw)-1], 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	unit := rangeRaw[len(rangeRaw)-1]
+
+	switch {
+	case format == "borg" && unit == 'h':
+		unit = 'H'
+	case format == "borg" && strings.IndexByte("dw", unit) != -1:
+	case format == "restic" && unit == 'w':
+		number, unit = number*7, 'd'
+	case format == "restic" && strings.IndexByte("hdmy", unit) != -1:
+	default:
+		return "", fmt.Errorf("range %s cannot be expressed for %s", rangeRaw, format)
+	}
+
+	return fmt.Sprintf("%d%c", number, unit), nil
+}
+
+//
+
+var interopSanoidNameReplacer = strings.NewReplacer("/", "-", " ", "-", ":", "-", "@", "-")
+
+var interopSanoidRules = []string{"frequently", "hourly", "daily", "weekly", "monthly", "yearly"}
+
+// interopSanoidDefaults are the values of template_default in the defaults of
+// sanoid, which apply to every dataset.
+var interopSanoidDefaults = map[string]string{
+	"frequent_period": "15",
+	"frequently":      "0",
+	"hourly":          "48",
+	"daily":           "90",
+	"weekly":          "0",
+	"monthly":         "6",
+	"yearly":          "0",
+}
+
+func importSanoid(input string) ([]string, error) {
+	var lines []string
+	var section string
+	var sectionNames []string
+
+	sections := map[string]map[string]string{}
+
+	s := bufio.NewScanner(strings.NewReader(input))
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+
+			if _, known := sections[section]; known {
+				return nil, fmt.Errorf("parsing line: duplicate section: %s", line)
+			}
+
+			sections[section] = map[string]string{}
+			sectionNames = append(sectionNames, section)
+
+			continue
+		} else if section == "" {
+			return nil, fmt.Errorf("parsing line: expected section: %s", line)
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("parsing line: expected key = value: %s", line)
+		}
+
+		sections[section][strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	var datasets []string
+
+	for _, section := range sectionNames {
+		if !strings.HasPrefix(section, "template_") {
+			datasets = append(datasets, section)
+		}
+	}
+
+	if len(datasets) == 0 {
+		// without datasets, import each template as if a dataset used it
+		datasets = sectionNames
+	}
+
+	if len(datasets) == 0 {
+		return nil, errors.New("no sections found")
+	}
+
+	for _, section := range datasets {
+		sectionLines, err := importSanoidSection(section, sections)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %v", section, err)
+		}
+
+		lines = append(lines, sectionLines...)
+	}
+
+	return lines, nil
+}
+
+// importSanoidSection translates the retention of a section after resolving it
+// as sanoid does: its defaults, then template_default, then each template of
+// use_template, then the values of the section.
+func importSanoidSection(section string, sections map[string]map[string]string) ([]string, error) {
+	values := map[string]string{}
+	origins := map[string]string{}
+
+	resolve := func(origin string, sectionValues map[string]string) {
+		for key, value := range sectionValues {
+			values[key] = value
+			origins[key] = origin
+		}
+	}
+
+	resolve("sanoid default", interopSanoidDefaults)
+	resolve("template_default", sections["template_default"])
+
+	if useTemplate, ok := sections[section]["use_template"]; ok && !strings.HasPrefix(section, "template_") {
+		for _, template := range strings.Split(useTemplate, ",") {
+			template = "template_" + strings.TrimSpace(template)
+
+			templateValues, known := sections[template]
+			if !known {
+				return nil, fmt.Errorf("parsing use_template: unknown template: %s", template)
+			}
+
+			resolve(template, templateValues)
+		}
+	}
+
+	resolve(section, sections[section])
+
+	frequentPeriod, err := strconv.Atoi(values["frequent_period"])
+	if err != nil || frequentPeriod < 1 {
+		return nil, errors.New("parsing frequent_period: expected minutes greater than 0")
+	}
+
+	lines := []string{fmt.Sprintf("# [%s]", section)}
+
+	for _, rule := range interopSanoidRules {
+		count, err := strconv.Atoi(values[rule])
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("parsing %s: expected a count", rule)
+		} else if count == 0 {
+			continue
+		}
+
+		name := fmt.Sprintf("%s-%s", interopSanoidNameReplacer.Replace(strings.TrimPrefix(section, "template_")), rule)
+
+		comment := fmt.Sprintf("%s = %d", rule, count)
+		if origins[rule] != section {
+			comment = fmt.Sprintf("%s (%s)", comment, origins[rule])
+		}
+
+		// sanoid keeps the newest snapshots of a type, and any newer than the
+		// count of periods
+		lines = append(
+			lines,
+			fmt.Sprintf("max=%d;if=%s;name=%s // %s", count, interopSanoidCondition(rule), name, comment),
+			fmt.Sprintf("%s;if=%s;name=%s-age // %s", interopSanoidRange(rule, count, frequentPeriod), interopSanoidCondition(rule), name, comment),
+		)
+	}
+
+	return lines, nil
+}
+
+// interopSanoidCondition returns the condition matching snapshots of a type,
+// whose names end with the type (e.g. autosnap_2023-01-01_00:00:00_hourly).
+func interopSanoidCondition(rule string) string {
+	return fmt.Sprintf(`entry.contains("_%s")`, rule)
+}
+
+// interopSanoidRange returns the range of a count of periods, using the period
+// lengths of sanoid; months and years are always 31 and 365 days.
+func interopSanoidRange(rule string, count int, frequentPeriod int) string {
+	switch rule {
+	case "frequently":
+		return fmt.Sprintf("%ds", count*frequentPeriod*60)
+	case "hourly":
+		return fmt.Sprintf("%dh", count)
+	case "weekly":
+		return fmt.Sprintf("%dw", count)
+	case "monthly":
+		return fmt.Sprintf("%dd", count*31)
+	case "yearly":
+		return fmt.Sprintf("%dd", count*365)
+	}
+
+	return fmt.Sprintf("%dd", count)
+}
+
+func exportSanoid(specs []*PolicySpec) (string, []string, error) {
+	var differences []string
+
+	counts := map[string]int{}
+	ranges := map[string]string{}
+
+	for _, spec := range specs {
+		var rule string
+
+		for _, candidate := range interopSanoidRules[1:] {
+			if spec.conditionExpr == interopSanoidCondition(candidate) {
+				rule = candidate
+			}
+		}
+
+		if rule != "" && spec.bucket == nil && spec.last == 0 && !spec.fill && !spec.oldest && spec.prefer == nil && spec.spacing == 0 {
+			if spec.cutoffFunc == nil && spec.max > 0 {
+				if _, known := counts[rule]; known {
+					return "", nil, fmt.Errorf("policy %s: %s is already configured", spec.name, rule)
+				}
+
+				counts[rule] = spec.max
+
+				continue
+			} else if spec.cutoffFunc != nil && spec.max == -1 {
+				if _, known := ranges[rule]; known {
+					return "", nil, fmt.Errorf("policy %s: %s is already configured", spec.name, rule)
+				}
+
+				ranges[rule] = spec.rangeRaw
+
+				continue
+			}
+		}
+
+		rule = interopBucketNames[spec.bucketEnum]
+
+		if interopSpecHasBehavior(spec) || rule == "" || spec.cutoffFunc == nil || spec.max != 1 || spec.last != 0 || spec.fill {
+			return "", nil, fmt.Errorf("policy %s: only max={INT} and range policies with if=entry.contains(\"_TYPE\"), or {INT}{UNIT};by={UNIT} policies, can be exported", spec.name)
+		} else if spec.rangeRaw[len(spec.rangeRaw)-1] != interopBucketRangeUnits[spec.bucketEnum] {
+			return "", nil, fmt.Errorf("policy %s: range unit must match by=%s", spec.name, spec.bucketEnum)
+		} else if _, known := counts[rule]; known {
+			return "", nil, fmt.Errorf("policy %s: %s is already configured", spec.name, rule)
+		}
+
+		count, err := strconv.Atoi(spec.rangeRaw[0 : len(spec.rangeRaw)-1])
+		if err != nil {
+			return "", nil, fmt.Errorf("policy %s: %v", spec.name, err)
+		}
+
+		// a range of buckets approximates both the count and the age
+		counts[rule] = count
+		ranges[rule] = interopSanoidRange(rule, count, 0)
+		differences = []string{interopDifferenceSanoidTypes}
+	}
+
+	var b strings.Builder
+
+	b.WriteString("[template_timepolicy]\n")
+
+	for _, rule := range interopSanoidRules[1:] {
+		count := counts[rule]
+
+		if rangeRaw, known := ranges[rule]; known && count == 0 {
+			return "", nil, fmt.Errorf("%s: range %s requires a max policy", rule, rangeRaw)
+		} else if known && rangeRaw != interopSanoidRange(rule, count, 0) {
+			return "", nil, fmt.Errorf("%s: range %s does not match max=%d", rule, rangeRaw, count)
+		} else if !known && count > 0 {
+			differences = []string{interopDifferenceSanoidTypes}
+		}
+
+		fmt.Fprintf(&b, "\t%s = %d\n", rule, count)
+	}
+
+	return b.String(), differences, nil
+}
+
+// interopSpecHasBehavior returns whether the policy uses qualifiers which other
+// tools cannot express.
+func interopSpecHasBehavior(spec *PolicySpec) bool {
+	return spec.condition != nil || spec.bucketExpr != "" || spec.oldest || spec.edges || spec.prefer != nil || spec.at != nil || spec.spacing > 0
+}
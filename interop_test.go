@@ -0,0 +1,685 @@
+package timepolicy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// interopTestNow is the reference of the command, after the newest entry of
+// every timeline.
+var interopTestNow = mustParseRFC3339("2023-01-01T01:02:03Z")
+
+// interopTestTimelines are sample entries, newest first. The newest entry is
+// off the usual schedule so ranges do not end exactly on an entry. The short
+// and sparse timelines have fewer periods than most counts, and their oldest
+// entries share a period with another.
+func interopTestTimelines() map[string][]time.Time {
+	var nightly, irregular []time.Time
+
+	nightly = append(nightly, mustParseRFC3339("2022-12-31T15:30:00Z"))
+
+	for t := mustParseRFC3339("2022-12-31T02:00:00Z"); t.Year() == 2022; t = t.AddDate(0, 0, -1) {
+		nightly = append(nightly, t)
+	}
+
+	irregular = append(irregular, mustParseRFC3339("2022-12-31T21:45:00Z"))
+
+	for t := mustParseRFC3339("2022-12-31T19:00:00Z"); t.After(mustParseRFC3339("2022-08-01T00:00:00Z")); t = t.Add(-7 * time.Hour) {
+		if t.Day()%5 == 0 {
+			continue
+		}
+
+		irregular = append(irregular, t)
+	}
+
+	var short, sparse []time.Time
+
+	for _, raw := range []string{"2022-12-31T21:45:00Z", "2022-12-31T08:00:00Z", "2022-12-30T20:00:00Z", "2022-12-30T09:00:00Z"} {
+		short = append(short, mustParseRFC3339(raw))
+	}
+
+	for _, raw := range []string{"2022-12-02T10:00:00Z", "2022-11-20T10:00:00Z", "2022-09-14T03:00:00Z", "2022-09-14T01:00:00Z", "2021-06-01T12:00:00Z", "2021-06-01T06:00:00Z"} {
+		sparse = append(sparse, mustParseRFC3339(raw))
+	}
+
+	return map[string][]time.Time{
+		"nightly":   nightly,
+		"irregular": irregular,
+		"short":     short,
+		"sparse":    sparse,
+	}
+}
+
+func TestImportResticAgrees(t *testing.T) {
+	for _, args := range []string{
+		"--keep-daily 7 --keep-weekly 4 --keep-monthly 2",
+		"--keep-last 3 --keep-hourly 5",
+		"-d 7 -w 4 -m 3 -y 1",
+		"--keep-daily -1 --keep-monthly 6",
+		"--keep-hourly 2",
+	} {
+		for timelineName, timeline := range interopTestTimelines() {
+			t.Run(fmt.Sprintf("%s/%s", args, timelineName), func(t *testing.T) {
+				if _e, _a := interopTestRestic(t, args, timeline), interopTestSelect(t, "restic", args, timeline, interopTestNow, false); _e != _a {
+					t.Fatalf("expected `%v` but got: %v", _e, _a)
+				}
+			})
+		}
+
+		if _, differences, err := ImportPolicies("restic", args); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		} else if _e, _a := 0, len(differences); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+}
+
+func TestImportResticRanges(t *testing.T) {
+	for _, args := range []string{
+		"--keep-within 10d",
+		"--keep-within-daily 14d --keep-weekly 3",
+		"--keep-within=2m --keep-daily=10",
+	} {
+		_, differences, err := ImportPolicies("restic", args)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		} else if _e, _a := interopDifferenceResticRange, strings.Join(differences, "; "); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+
+		// only the reference differs
+		for timelineName, timeline := range interopTestTimelines() {
+			t.Run(fmt.Sprintf("%s/%s", args, timelineName), func(t *testing.T) {
+				if _e, _a := interopTestRestic(t, args, timeline), interopTestSelect(t, "restic", args, timeline, timeline[0], false); _e != _a {
+					t.Fatalf("expected `%v` but got: %v", _e, _a)
+				}
+			})
+		}
+	}
+
+	sparse := interopTestTimelines()["sparse"]
+
+	if _e, _a := interopTestRestic(t, "--keep-within 10d", sparse), interopTestSelect(t, "restic", "--keep-within 10d", sparse, interopTestNow, false); _e == _a {
+		t.Fatalf("expected `%v` to differ from: %v", _e, _a)
+	}
+}
+
+func TestImportBorgAgrees(t *testing.T) {
+	for _, args := range []string{
+		"--keep-daily 7",
+		"-w 4",
+		"--keep-monthly=3",
+		"--keep-yearly 2",
+		"--keep-hourly 3",
+		"--keep-within 10d",
+		"--keep-within 1m",
+		"--keep-last 5",
+	} {
+		for timelineName, timeline := range interopTestTimelines() {
+			t.Run(fmt.Sprintf("%s/%s", args, timelineName), func(t *testing.T) {
+				if _e, _a := interopTestBorg(t, args, timeline, interopTestNow), interopTestSelect(t, "borg", args, timeline, interopTestNow, false); _e != _a {
+					t.Fatalf("expected `%v` but got: %v", _e, _a)
+				}
+			})
+		}
+
+		if _, differences, err := ImportPolicies("borg", args); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		} else if _e, _a := 0, len(differences); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+}
+
+func TestImportBorgRules(t *testing.T) {
+	irregular := interopTestTimelines()["irregular"]
+
+	for _, args := range []string{
+		"--keep-daily 7 --keep-weekly 4",
+		"--keep-within 20H --keep-daily 7",
+	} {
+		_, differences, err := ImportPolicies("borg", args)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		} else if _e, _a := interopDifferenceBorgRules, strings.Join(differences, "; "); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+
+		// neither evaluation matches rules which skip periods
+		for _, exclusive := range []bool{false, true} {
+			if _e, _a := interopTestBorg(t, args, irregular, interopTestNow), interopTestSelect(t, "borg", args, irregular, interopTestNow, exclusive); _e == _a {
+				t.Fatalf("expected `%v` to differ from: %v", _e, _a)
+			}
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		input  string
+	}{
+		{"restic", "--keep-tag important"},
+		{"restic", "--keep-daily"},
+		{"restic", "--keep-daily 0"},
+		{"restic", "--keep-within 1y6m"},
+		{"restic", "--keep-minutely 5"},
+		{"borg", "--keep-daily -1"},
+		{"borg", "--keep-within-daily 7d"},
+		{"sanoid", "hourly = 36"},
+		{"sanoid", "[template_x]\n\thourly = many"},
+		{"sanoid", "[tank]\n\tuse_template = missing"},
+		{"sanoid", "[tank]\n[tank]"},
+		{"unknown", ""},
+	} {
+		if _, _, err := ImportPolicies(tc.format, tc.input); err == nil {
+			t.Fatalf("expected error for %s %s but got: %v", tc.format, tc.input, err)
+		}
+	}
+}
+
+func TestImportSanoid(t *testing.T) {
+	lines, differences, err := ImportPolicies("sanoid", `
+[tank/data]
+	use_template = production
+	daily = 7
+
+[tank/scratch]
+	hourly = 12
+
+# retention
+[template_production]
+	frequently = 4
+	hourly = 36
+	daily = 30
+	monthly = 3
+	yearly = 0
+	autosnap = yes
+	autoprune = yes
+`)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := 0, len(differences); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 16, len(lines); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := `# [tank/data],max=4;if=entry.contains("_frequently");name=tank-data-frequently,3600s;if=entry.contains("_frequently");name=tank-data-frequently-age,max=36;if=entry.contains("_hourly");name=tank-data-hourly,36h;if=entry.contains("_hourly");name=tank-data-hourly-age,max=7;if=entry.contains("_daily");name=tank-data-daily,7d;if=entry.contains("_daily");name=tank-data-daily-age,max=3;if=entry.contains("_monthly");name=tank-data-monthly,93d;if=entry.contains("_monthly");name=tank-data-monthly-age`, strings.Join(presetTestStripComments(lines[0:9]), ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := `max=36;if=entry.contains("_hourly");name=tank-data-hourly // hourly = 36 (template_production)`, lines[3]; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := `max=7;if=entry.contains("_daily");name=tank-data-daily // daily = 7`, lines[5]; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := `max=90;if=entry.contains("_daily");name=tank-scratch-daily // daily = 90 (sanoid default)`, lines[12]; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	specs := interopTestParse(t, lines[0:9])
+
+	if _, _, err := ExportPolicies("sanoid", specs); err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+
+	exported, differences, err := ExportPolicies("sanoid", specs[2:])
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "[template_timepolicy]\n\thourly = 36\n\tdaily = 7\n\tweekly = 0\n\tmonthly = 3\n\tyearly = 0\n", exported; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 0, len(differences); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	lines, _, err = ImportPolicies("sanoid", "[template_default]\n\tmonthly = 0\n\n[template_x]\n\thourly = 12\n")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := 10, len(lines); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "# [template_x]", lines[5]; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := `max=12;if=entry.contains("_hourly");name=x-hourly // hourly = 12`, lines[6]; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
+
+func TestImportSanoidAgrees(t *testing.T) {
+	for _, tc := range []struct {
+		config         string
+		counts         map[string]int
+		frequentPeriod int
+	}{
+		{
+			config:         "[tank]\n\tfrequently = 4\n\thourly = 36\n\tdaily = 30\n\tweekly = 2\n\tmonthly = 3\n\tyearly = 1\n",
+			counts:         map[string]int{"frequently": 4, "hourly": 36, "daily": 30, "weekly": 2, "monthly": 3, "yearly": 1},
+			frequentPeriod: 15,
+		},
+		{
+			config:         "[tank]\n\tuse_template = backup\n\n[template_backup]\n\tfrequent_period = 30\n\tfrequently = 2\n\thourly = 12\n",
+			counts:         map[string]int{"frequently": 2, "hourly": 12, "daily": 90, "monthly": 6},
+			frequentPeriod: 30,
+		},
+	} {
+		lines, _, err := ImportPolicies("sanoid", tc.config)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		for timelineName, timeline := range interopTestTimelines() {
+			t.Run(fmt.Sprintf("%s/%s", tc.config, timelineName), func(t *testing.T) {
+				snapshots := interopTestSanoidSnapshots(timeline, tc.frequentPeriod)
+
+				if _e, _a := interopTestSanoid(tc.counts, tc.frequentPeriod, snapshots, interopTestNow), interopTestEvaluate(t, lines, snapshots, interopTestNow, false); _e != _a {
+					t.Fatalf("expected `%v` but got: %v", _e, _a)
+				}
+			})
+		}
+	}
+}
+
+func TestExportPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		format      string
+		policies    []string
+		expected    string
+		differences []string
+	}{
+		{"restic", []string{"by=day;last=7;fill", "by=week;last=4;fill", "max=3"}, "--keep-daily 7 --keep-weekly 4 --keep-last 3", nil},
+		{"restic", []string{"by=day;last=7"}, "--keep-daily 7", []string{fmt.Sprintf(interopDifferenceFill, "restic")}},
+		{"restic", []string{"2w", "14d;by=day;fill", "by=month;fill"}, "--keep-within 14d --keep-within-daily 14d --keep-monthly -1", []string{interopDifferenceResticRange}},
+		{"borg", []string{"by=hour;last=24;fill"}, "--keep-hourly 24", nil},
+		{"borg", []string{"by=1s;last=5;fill"}, "--keep-last 5", nil},
+		{"borg", []string{"12h"}, "--keep-within 12H", nil},
+		{"borg", []string{"by=day;last=7", "12h", "2w", "max=5"}, "--keep-daily 7 --keep-within 12H --keep-within 2w --keep-last 5", []string{interopDifferenceBorgRules, fmt.Sprintf(interopDifferenceFill, "borg")}},
+		{"restic", []string{"7d;by=day;oldest"}, "", nil},
+		{"restic", []string{"by=6h;last=4"}, "", nil},
+		{"restic", []string{"30s"}, "", nil},
+		{"borg", []string{"14d;by=day"}, "", nil},
+		{"borg", []string{"1y"}, "", nil},
+		{"borg", []string{"max=-1"}, "", nil},
+		{"sanoid", []string{`max=36;if=entry.contains("_hourly")`, `36h;if=entry.contains("_hourly")`, `max=3;if=entry.contains("_monthly")`, `93d;if=entry.contains("_monthly")`}, "[template_timepolicy]\n\thourly = 36\n\tdaily = 0\n\tweekly = 0\n\tmonthly = 3\n\tyearly = 0\n", nil},
+		{"sanoid", []string{"36h;by=hour", "3m;by=month"}, "[template_timepolicy]\n\thourly = 36\n\tdaily = 0\n\tweekly = 0\n\tmonthly = 3\n\tyearly = 0\n", []string{interopDifferenceSanoidTypes}},
+		{"sanoid", []string{`max=36;if=entry.contains("_hourly")`}, "[template_timepolicy]\n\thourly = 36\n\tdaily = 0\n\tweekly = 0\n\tmonthly = 0\n\tyearly = 0\n", []string{interopDifferenceSanoidTypes}},
+		{"sanoid", []string{`max=36;if=entry.contains("_hourly")`, `30h;if=entry.contains("_hourly")`}, "", nil},
+		{"sanoid", []string{`36h;if=entry.contains("_hourly")`}, "", nil},
+		{"sanoid", []string{`max=4;if=entry.contains("_frequently")`}, "", nil},
+		{"sanoid", []string{"7d;by=hour"}, "", nil},
+		{"sanoid", []string{"by=day;last=7"}, "", nil},
+		{"sanoid", []string{"7d;by=day;fill"}, "", nil},
+	} {
+		actual, differences, err := ExportPolicies(tc.format, interopTestParse(t, tc.policies))
+		if tc.expected == "" && err == nil {
+			t.Fatalf("expected error for %s %v but got: %v", tc.format, tc.policies, actual)
+		} else if tc.expected != "" && err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		} else if _e, _a := tc.expected, actual; _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		} else if _e, _a := strings.Join(tc.differences, "; "), strings.Join(differences, "; "); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		args   string
+	}{
+		{"restic", "--keep-last 3 --keep-daily 7 --keep-weekly 4 --keep-monthly 12 --keep-yearly 5"},
+		{"restic", "--keep-within 3m --keep-within-hourly 2d --keep-daily -1"},
+		{"borg", "--keep-within 12H --keep-hourly 24 --keep-daily 7"},
+		{"borg", "--keep-last 5"},
+	} {
+		lines, importDifferences, err := ImportPolicies(tc.format, tc.args)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		actual, exportDifferences, err := ExportPolicies(tc.format, interopTestParse(t, lines))
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		} else if _e, _a := tc.args, actual; _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		} else if _e, _a := strings.Join(importDifferences, "; "), strings.Join(exportDifferences, "; "); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+}
+
+//
+
+func interopTestParse(t *testing.T, lines []string) []*PolicySpec {
+	var specs []*PolicySpec
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		spec, err := ParsePolicySpecString(fmt.Sprintf("policy-%d", len(specs)), line)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs
+}
+
+// interopTestSelect returns the entries selected by the imported policies, as
+// the command would with a reference.
+func interopTestSelect(t *testing.T, format string, args string, timeline []time.Time, reference time.Time, exclusive bool) string {
+	lines, _, err := ImportPolicies(format, args)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	var entries []*Entry
+
+	for _, ts := range timeline {
+		entries = append(entries, &Entry{Raw: ts.Format(time.RFC3339), Time: ts})
+	}
+
+	return interopTestEvaluate(t, lines, entries, reference, exclusive)
+}
+
+// interopTestEvaluate returns the raw entries selected by the policies of lines.
+func interopTestEvaluate(t *testing.T, lines []string, entries []*Entry, reference time.Time, exclusive bool) string {
+	specs := interopTestParse(t, lines)
+
+	for specIdx, spec := range specs {
+		specs[specIdx] = spec.Resolve(reference)
+	}
+
+	pss := NewPolicySelectionSet(specs, NewDiscardEntryWriter())
+	pss.SetExclusive(exclusive)
+
+	for _, e := range entries {
+		if _, err := pss.EvaluateEntry(e); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if err := pss.Flush(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	var actual []string
+
+	for _, e := range pss.Entries() {
+		actual = append(actual, e.Raw)
+	}
+
+	sort.Strings(actual)
+
+	return strings.Join(actual, ",")
+}
+
+// interopTestFlags returns the keep flags as rule to value, expanding short
+// flags.
+func interopTestFlags(t *testing.T, format string, args string) map[string]string {
+	flags := map[string]string{}
+	fields := strings.Fields(args)
+
+	for fieldIdx := 0; fieldIdx < len(fields); fieldIdx++ {
+		flag, value, ok := strings.Cut(fields[fieldIdx], "=")
+		if !ok {
+			fieldIdx++
+			value = fields[fieldIdx]
+		}
+
+		if long, ok := interopKeepShortFlags[format][flag]; ok {
+			flag = long
+		}
+
+		flags[strings.TrimPrefix(flag, "--keep-")] = value
+	}
+
+	return flags
+}
+
+var interopTestBuckets = map[string]func(t time.Time) string{
+	"secondly": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"hourly":   func(t time.Time) string { return t.Format("2006-01-02 15") },
+	"daily":    func(t time.Time) string { return t.Format("2006-01-02") },
+	"weekly": func(t time.Time) string {
+		y, w := t.ISOWeek()
+
+		return fmt.Sprintf("%d-%02d", y, w)
+	},
+	"monthly": func(t time.Time) string { return t.Format("2006-01") },
+	"yearly":  func(t time.Time) string { return t.Format("2006") },
+}
+
+// interopTestRestic follows the policy of restic forget for the supported
+// flags, with timeline ordered newest first.
+func interopTestRestic(t *testing.T, args string, timeline []time.Time) string {
+	flags := interopTestFlags(t, "restic", args)
+
+	within := func(value string) func(ts time.Time) bool {
+		if value == "" {
+			return nil
+		}
+
+		n, err := strconv.Atoi(value[0 : len(value)-1])
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		var target time.Time
+
+		switch value[len(value)-1] {
+		case 'y':
+			target = timeline[0].AddDate(-n, 0, 0)
+		case 'm':
+			target = timeline[0].AddDate(0, -n, 0)
+		case 'd':
+			target = timeline[0].AddDate(0, 0, -n)
+		case 'h':
+			target = timeline[0].Add(time.Duration(-n) * time.Hour)
+		}
+
+		return func(ts time.Time) bool {
+			return ts.After(target)
+		}
+	}
+
+	type resticBucket struct {
+		count  int
+		bucket func(t time.Time) string
+		within func(ts time.Time) bool
+		last   string
+	}
+
+	var buckets []*resticBucket
+
+	for _, rule := range []string{"hourly", "daily", "weekly", "monthly", "yearly"} {
+		if value, ok := flags[rule]; ok {
+			count, _ := strconv.Atoi(value)
+			buckets = append(buckets, &resticBucket{count: count, bucket: interopTestBuckets[rule]})
+		}
+
+		if value, ok := flags["within-"+rule]; ok {
+			buckets = append(buckets, &resticBucket{count: -1, bucket: interopTestBuckets[rule], within: within(value)})
+		}
+	}
+
+	keepLast, _ := strconv.Atoi(flags["last"])
+	keepWithin := within(flags["within"])
+
+	var kept []string
+
+	for nr, ts := range timeline {
+		keep := nr < keepLast || (keepWithin != nil && keepWithin(ts))
+
+		for _, b := range buckets {
+			if b.count == 0 || (b.within != nil && !b.within(ts)) {
+				continue
+			}
+
+			if key := b.bucket(ts); key != b.last || nr == len(timeline)-1 {
+				keep = true
+				b.last = key
+
+				if b.count > 0 {
+					b.count--
+				}
+			}
+		}
+
+		if keep {
+			kept = append(kept, ts.Format(time.RFC3339))
+		}
+	}
+
+	sort.Strings(kept)
+
+	return strings.Join(kept, ",")
+}
+
+// interopTestBorg follows borg prune for the supported flags, with timeline
+// ordered newest first.
+func interopTestBorg(t *testing.T, args string, timeline []time.Time, now time.Time) string {
+	flags := interopTestFlags(t, "borg", args)
+	kept := map[time.Time]struct{}{}
+
+	if value, ok := flags["within"]; ok {
+		n, err := strconv.Atoi(value[0 : len(value)-1])
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		hours := map[byte]int{'H': 1, 'd': 24, 'w': 24 * 7, 'm': 24 * 31, 'y': 24 * 365}[value[len(value)-1]]
+		target := now.Add(time.Duration(-n*hours) * time.Hour)
+
+		for _, ts := range timeline {
+			if ts.After(target) {
+				kept[ts] = struct{}{}
+			}
+		}
+	}
+
+	if value, ok := flags["last"]; ok {
+		flags["secondly"] = value
+	}
+
+	for _, rule := range []string{"secondly", "hourly", "daily", "weekly", "monthly", "yearly"} {
+		value, ok := flags[rule]
+		if !ok {
+			continue
+		}
+
+		n, _ := strconv.Atoi(value)
+
+		var last string
+		var keep int
+
+		for _, ts := range timeline {
+			if key := interopTestBuckets[rule](ts); key != last {
+				last = key
+
+				if _, known := kept[ts]; !known {
+					kept[ts] = struct{}{}
+					keep++
+
+					if keep == n {
+						break
+					}
+				}
+			}
+		}
+
+		if oldest := timeline[len(timeline)-1]; keep < n {
+			kept[oldest] = struct{}{}
+		}
+	}
+
+	var actual []string
+
+	for ts := range kept {
+		actual = append(actual, ts.Format(time.RFC3339))
+	}
+
+	sort.Strings(actual)
+
+	return strings.Join(actual, ",")
+}
+
+// interopTestSanoidSnapshots returns the snapshots sanoid would take of each
+// type for the timeline, the first of each period, ordered oldest first.
+func interopTestSanoidSnapshots(timeline []time.Time, frequentPeriod int) []*Entry {
+	var snapshots []*Entry
+
+	for _, rule := range interopSanoidRules {
+		var last string
+
+		for tsIdx := len(timeline) - 1; tsIdx >= 0; tsIdx-- {
+			ts := timeline[tsIdx]
+
+			var key string
+
+			if rule == "frequently" {
+				key = ts.Truncate(time.Duration(frequentPeriod) * time.Minute).Format(time.RFC3339)
+			} else {
+				key = interopTestBuckets[rule](ts)
+			}
+
+			if key == last {
+				continue
+			}
+
+			last = key
+			snapshots = append(snapshots, &Entry{
+				Raw:  fmt.Sprintf("tank@autosnap_%s_%s", ts.Format("2006-01-02_15:04:05"), rule),
+				Time: ts,
+			})
+		}
+	}
+
+	return snapshots
+}
+
+// interopTestSanoid follows the pruning of sanoid, with snapshots ordered oldest
+// first. Snapshots of a type are pruned while older than the count of periods
+// and more than the count remain.
+func interopTestSanoid(counts map[string]int, frequentPeriod int, snapshots []*Entry, now time.Time) string {
+	periods := map[string]time.Duration{
+		"frequently": time.Duration(frequentPeriod) * time.Minute,
+		"hourly":     time.Hour,
+		"daily":      24 * time.Hour,
+		"weekly":     7 * 24 * time.Hour,
+		"monthly":    31 * 24 * time.Hour,
+		"yearly":     365 * 24 * time.Hour,
+	}
+
+	var kept []string
+
+	for _, rule := range interopSanoidRules {
+		var typed []*Entry
+
+		for _, snapshot := range snapshots {
+			if strings.HasSuffix(snapshot.Raw, "_"+rule) {
+				typed = append(typed, snapshot)
+			}
+		}
+
+		maxAge := now.Add(-time.Duration(counts[rule]) * periods[rule])
+		remaining := len(typed)
+
+		for _, snapshot := range typed {
+			if snapshot.Time.Before(maxAge) && remaining > counts[rule] {
+				remaining--
+
+				continue
+			}
+
+			kept = append(kept, snapshot.Raw)
+		}
+	}
+
+	sort.Strings(kept)
+
+	return strings.Join(kept, ",")
+}
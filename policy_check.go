@@ -0,0 +1,49 @@
+package timepolicy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FindMissingBuckets returns the keys of the buckets of the policy which have
+// no matching entries. Only complete buckets overlapping the range of the policy
+// are expected; the bucket containing the reference time is still in progress
+// and is ignored. Buckets are enumerated in loc so keys align with entries.
+func FindMissingBuckets(spec *PolicySpec, entries []*Entry, loc *time.Location) ([]string, error) {
+	if spec.cutoffFunc == nil {
+		return nil, errors.New("policy must have a range")
+	}
+
+	enum := spec.bucketUnit
+	if enum == nil {
+		return nil, errors.New("policy must use a simple or duration by value (e.g. by=day or by=6h)")
+	}
+
+	found := map[string]struct{}{}
+
+	for _, e := range entries {
+		match, err := spec.matchCondition(e)
+		if err != nil {
+			return nil, fmt.Errorf("matching entry: %v", err)
+		} else if !match {
+			continue
+		}
+
+		found[enum.key(e.Time.In(loc))] = struct{}{}
+	}
+
+	var missing []string
+
+	reference := spec.reference.In(loc)
+
+	for start := enum.start(spec.cutoff.In(loc)); !enum.next(start).After(reference); start = enum.next(start) {
+		key := enum.key(start)
+
+		if _, known := found[key]; !known {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,64 @@
+package timepolicy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindMissingBuckets(t *testing.T) {
+	Now = stubNow
+
+	spec, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	var entries []*Entry
+
+	for _, ts := range []string{
+		"2022-12-25T00:30:00Z",
+		"2022-12-26T12:00:00Z",
+		"2022-12-28T12:00:00Z",
+		"2022-12-29T12:00:00Z",
+		"2022-12-30T12:00:00Z",
+		"2022-12-31T12:00:00Z",
+	} {
+		entries = append(entries, &Entry{Raw: ts, Time: mustParseRFC3339(ts)})
+	}
+
+	missing, err := FindMissingBuckets(spec, entries, time.UTC)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "2022-12-27", strings.Join(missing, ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	missing, err = FindMissingBuckets(spec, nil, time.UTC)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := 7, len(missing); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
+
+func TestFindMissingBucketsHourOffset(t *testing.T) {
+	Now = func() time.Time {
+		return mustParseRFC3339("2023-01-01T00:45:00Z")
+	}
+
+	spec, err := ParsePolicySpecString("hourly", "3h;by=hour")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	// hours start at :30 of UTC hours
+	loc := time.FixedZone("IST", 5*60*60+30*60)
+
+	missing, err := FindMissingBuckets(spec, []*Entry{{Time: mustParseRFC3339("2022-12-31T22:40:00Z")}}, loc)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "2023-01-01T03,2023-01-01T05", strings.Join(missing, ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
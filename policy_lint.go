@@ -0,0 +1,161 @@
+package timepolicy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PolicyLintIssue struct {
+	Spec    *PolicySpec
+	Policy  string
+	Message string
+}
+
+// LintPolicySpecs returns warnings about policies which are valid but are
+// unlikely to behave as intended.
+func LintPolicySpecs(specs []*PolicySpec) []PolicyLintIssue {
+	var issues []PolicyLintIssue
+
+	reference := Now()
+	resolved := make([]*PolicySpec, len(specs))
+
+	for specIdx, spec := range specs {
+		resolved[specIdx] = spec.Resolve(reference)
+	}
+
+	for specIdx, spec := range resolved {
+		addIssue := func(format string, args ...interface{}) {
+			issues = append(issues, PolicyLintIssue{
+				Spec:    specs[specIdx],
+				Policy:  spec.name,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if spec.bucketTimeless {
+			addIssue("by expression does not reference ts; buckets will not change over time")
+		}
+
+		if spec.spacing > 0 && spec.max > 1 {
+			// entries are at least spacing apart, so a window only fits so many
+			var window time.Duration
+			var windowName string
+
+			if spec.bucketUnit != nil {
+				window, windowName = policyLintLongestBucket(spec), fmt.Sprintf("each by=%s bucket", spec.bucketEnum)
+			}
+
+			if rangeWindow := reference.Sub(spec.cutoff); spec.cutoffFunc != nil && (window == 0 || rangeWindow < window) {
+				window, windowName = rangeWindow, fmt.Sprintf("the %s range", spec.rangeRaw)
+			}
+
+			if maxEntries := int(window/spec.spacing) + 1; window > 0 && spec.max > maxEntries {
+				addIssue("max=%d can never be reached; spacing fits at most %d entries within %s", spec.max, maxEntries, windowName)
+			}
+		}
+
+		var shadowed bool
+
+		for otherIdx, other := range resolved {
+			if otherIdx == specIdx || !policyLintShadows(other, spec) {
+				continue
+			} else if otherIdx > specIdx && policyLintShadows(spec, other) {
+				// equivalent policies; only the later one is reported
+				continue
+			}
+
+			shadowed = true
+
+			addIssue("fully shadowed by %s; every entry it selects is also selected by %s", other.name, other.name)
+
+			break
+		}
+
+		if shadowed || spec.bucketEnum == "" {
+			continue
+		}
+
+		for _, other := range resolved[0:specIdx] {
+			if other.bucketEnum != spec.bucketEnum || other.conditionExpr != spec.conditionExpr || policyLintShadows(spec, other) {
+				continue
+			}
+
+			addIssue("overlaps %s; both select by=%s buckets within %s", other.name, spec.bucketEnum, policyLintShorterRange(spec, other))
+		}
+	}
+
+	return issues
+}
+
+// policyLintShadows returns whether every entry selected by b is also selected
+// by a, regardless of the entries evaluated.
+func policyLintShadows(a, b *PolicySpec) bool {
+	if a.conditionExpr != "" && a.conditionExpr != b.conditionExpr {
+		return false
+	} else if !a.cutoff.IsZero() && (b.cutoff.IsZero() || b.cutoff.Before(a.cutoff)) {
+		return false
+	} else if a.bucket == nil && a.max == -1 && a.spacing == 0 {
+		return true
+	} else if a.conditionExpr != b.conditionExpr || a.oldest != b.oldest || a.edges != b.edges || a.preferExpr != b.preferExpr || a.atRaw != b.atRaw || a.spacing != b.spacing || a.decay != b.decay || a.fill != b.fill {
+		return false
+	} else if a.max != -1 && (b.max == -1 || b.max > a.max) {
+		return false
+	} else if a.last > 0 && (b.last == 0 || b.last > a.last || a.bucketEnum != b.bucketEnum || a.bucketExpr != b.bucketExpr) {
+		// older buckets are evicted by the count of buckets rather than range
+		return false
+	} else if (a.atRaw != "" || a.spacing > 0) && a.bucketEnum != b.bucketEnum {
+		// targets are relative to the start of each bucket
+		return false
+	} else if (a.oldest || a.edges) && !a.cutoff.Equal(b.cutoff) {
+		// a shorter range changes which entries are oldest in a bucket
+		return false
+	}
+
+	if b.bucket == nil {
+		return true
+	} else if a.bucketExpr != "" {
+		return a.bucketExpr == b.bucketExpr
+	} else if a.bucketEnum == "" || b.bucketEnum == "" {
+		return false
+	} else if a.bucketEnum == b.bucketEnum {
+		return true
+	}
+
+	for _, within := range a.bucketUnit.within {
+		if within == b.bucketEnum {
+			return true
+		}
+	}
+
+	return false
+}
+
+// policyLintLongestBucket returns the longest duration of a bucket, allowing
+// for calendar months and years of varying length and daylight saving time.
+func policyLintLongestBucket(spec *PolicySpec) time.Duration {
+	switch strings.SplitN(spec.bucketEnum, "@", 2)[0] {
+	case "year":
+		return 366 * 24 * time.Hour
+	case "month":
+		return 31 * 24 * time.Hour
+	case "week", "day", "hour":
+		return spec.bucketUnit.period + time.Hour
+	}
+
+	return spec.bucketUnit.period
+}
+
+func policyLintShorterRange(a, b *PolicySpec) string {
+	if a.cutoffFunc == nil {
+		if b.cutoffFunc == nil {
+			return "all time"
+		}
+
+		return "the most recent " + b.rangeRaw
+	} else if b.cutoffFunc == nil || a.cutoff.After(b.cutoff) {
+		return "the most recent " + a.rangeRaw
+	}
+
+	return "the most recent " + b.rangeRaw
+}
@@ -0,0 +1,109 @@
+package timepolicy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLintPolicySpecs(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		policies []string
+		expected []string
+	}{
+		{
+			policies: []string{"7d;by=day", "12m;by=month", "5y;by=year"},
+			expected: nil,
+		},
+		{
+			policies: []string{"7d;by=day", "30d;by=hour"},
+			expected: []string{"policy-0: fully shadowed by policy-1"},
+		},
+		{
+			policies: []string{"7d;by=day;oldest", "30d;by=hour;oldest"},
+			expected: nil,
+		},
+		{
+			policies: []string{"7d;by=day;if=fields[1] == 'full'", "30d"},
+			expected: []string{"policy-0: fully shadowed by policy-1"},
+		},
+		{
+			policies: []string{"7d;by=day", "7d;by=day"},
+			expected: []string{"policy-1: fully shadowed by policy-0"},
+		},
+		{
+			policies: []string{"1d;by=day;max=24"},
+			expected: nil,
+		},
+		{
+			policies: []string{"7d;by=day;max=48;spacing=1h"},
+			expected: []string{"policy-0: max=48 can never be reached; spacing fits at most 26 entries within each by=day bucket"},
+		},
+		{
+			policies: []string{"7d;by=day;max=24;spacing=1h"},
+			expected: nil,
+		},
+		{
+			policies: []string{"6h;by=day;max=12;spacing=1h"},
+			expected: []string{"policy-0: max=12 can never be reached; spacing fits at most 7 entries within the 6h range"},
+		},
+		{
+			policies: []string{"30d;spacing=6h", "7d;by=day"},
+			expected: nil,
+		},
+		{
+			policies: []string{"30d;spacing=6h", "7d;spacing=6h"},
+			expected: []string{"policy-1: fully shadowed by policy-0"},
+		},
+		{
+			policies: []string{"by=day;last=7;fill", "by=day;last=7"},
+			expected: []string{"policy-1: overlaps policy-0; both select by=day buckets within all time"},
+		},
+		{
+			policies: []string{"by=day;last=7;fill", "by=day;last=7;fill"},
+			expected: []string{"policy-1: fully shadowed by policy-0"},
+		},
+		{
+			policies: []string{"30d;decay=2", "7d;by=day"},
+			expected: nil,
+		},
+		{
+			policies: []string{"decay=2;name=exponential", "7d;by=day;name=daily", "4w;by=week;name=weekly"},
+			expected: nil,
+		},
+		{
+			policies: []string{"1y;by=fields[0]"},
+			expected: []string{"policy-0: by expression does not reference ts"},
+		},
+		{
+			policies: []string{"7d;by=day;max=2", "14d;by=day;oldest"},
+			expected: []string{"policy-1: overlaps policy-0"},
+		},
+	} {
+		t.Run(strings.Join(tc.policies, " "), func(t *testing.T) {
+			var specs []*PolicySpec
+
+			for _, raw := range tc.policies {
+				spec, err := ParsePolicySpecString(fmt.Sprintf("policy-%d", len(specs)), raw)
+				if err != nil {
+					t.Fatalf("expected `nil` but got: %v", err)
+				}
+
+				specs = append(specs, spec)
+			}
+
+			issues := LintPolicySpecs(specs)
+			if _e, _a := len(tc.expected), len(issues); _e != _a {
+				t.Fatalf("expected `%v` but got: %v (%v)", _e, _a, issues)
+			}
+
+			for issueIdx, issue := range issues {
+				if _e, _a := tc.expected[issueIdx], issue.Policy+": "+issue.Message; !strings.HasPrefix(_a, _e) {
+					t.Fatalf("expected `%v` but got: %v", _e, _a)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package timepolicy
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ProjectEntryExpiry returns when each entry selected at reference would no
+// longer be selected by any policy, assuming no other entries are added and
+// entries are removed once they are no longer selected. Selected entries which
+// would never expire are included with a zero time. See SetExclusive for
+// exclusive.
+func ProjectEntryExpiry(specs []*PolicySpec, entries []*Entry, reference time.Time, exclusive bool) (map[*Entry]time.Time, error) {
+	selected, err := selectEntries(specs, entries, reference, exclusive)
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := map[*Entry]time.Time{}
+
+	for _, e := range selected {
+		expiry[e] = time.Time{}
+	}
+
+	for {
+		var next time.Time
+
+		for _, spec := range specs {
+			if spec.decay > 0 && len(selected) > 1 {
+				// spacing changes as entries enter later tiers of decay
+				span := selected[len(selected)-1].Time.Sub(selected[0].Time)
+
+				for _, e := range selected {
+					for tier := 1; time.Duration(float64(spec.spacing)*math.Pow(spec.decay, float64(tier-1))) <= span; tier++ {
+						tierStart := e.Time.Add(spec.decayTierAge(tier))
+						if !tierStart.After(reference) {
+							continue
+						}
+
+						if next.IsZero() || tierStart.Before(next) {
+							next = tierStart
+						}
+
+						break
+					}
+				}
+			}
+
+			if spec.cutoffFunc == nil {
+				continue
+			}
+
+			for _, e := range selected {
+				rangeEnd := policySpecRangeEnd(spec.cutoffFunc, e.Time)
+				if rangeEnd.After(reference) && (next.IsZero() || rangeEnd.Before(next)) {
+					next = rangeEnd
+				}
+			}
+		}
+
+		if next.IsZero() {
+			break
+		}
+
+		nextSelected, err := selectEntries(specs, selected, next, exclusive)
+		if err != nil {
+			return nil, err
+		}
+
+		nextSelectedSet := map[*Entry]struct{}{}
+
+		for _, e := range nextSelected {
+			nextSelectedSet[e] = struct{}{}
+		}
+
+		for _, e := range selected {
+			if _, known := nextSelectedSet[e]; !known {
+				expiry[e] = next
+			}
+		}
+
+		selected = nextSelected
+		reference = next
+	}
+
+	return expiry, nil
+}
+
+func selectEntries(specs []*PolicySpec, entries []*Entry, reference time.Time, exclusive bool) ([]*Entry, error) {
+	var resolved []*PolicySpec
+
+	for _, spec := range specs {
+		resolved = append(resolved, spec.Resolve(reference))
+	}
+
+	pss := NewPolicySelectionSet(resolved, NewDiscardEntryWriter())
+	pss.SetExclusive(exclusive)
+
+	for _, e := range entries {
+		if _, err := pss.EvaluateEntry(e); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := pss.Flush(); err != nil {
+		return nil, err
+	}
+
+	selected := pss.Entries()
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Time.Before(selected[j].Time)
+	})
+
+	return selected, nil
+}
+
+// policySpecRangeEnd returns the earliest reference for which t is before the
+// cutoff of a range.
+func policySpecRangeEnd(cutoffFunc func(reference time.Time) time.Time, t time.Time) time.Time {
+	lo, hi := t, t.Add(time.Second)
+
+	for !cutoffFunc(hi).After(t) {
+		lo, hi = hi, hi.Add(2*hi.Sub(t))
+	}
+
+	for hi.Sub(lo) > time.Nanosecond {
+		mid := lo.Add(hi.Sub(lo) / 2)
+
+		if cutoffFunc(mid).After(t) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	return hi
+}
@@ -0,0 +1,132 @@
+package timepolicy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectEntryExpiry(t *testing.T) {
+	Now = stubNow
+
+	daily, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	monthly, err := ParsePolicySpecString("monthly", "3m;by=month")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	entries := []*Entry{
+		{
+			Raw:  "entry-0",
+			Time: mustParseRFC3339("2022-12-31T18:00:00Z"),
+		},
+		{
+			Raw:  "entry-1",
+			Time: mustParseRFC3339("2022-12-30T12:00:00Z"),
+		},
+		{
+			Raw:  "entry-2",
+			Time: mustParseRFC3339("2022-11-15T00:00:00Z"),
+		},
+		{
+			Raw:  "entry-3",
+			Time: mustParseRFC3339("2022-11-14T00:00:00Z"),
+		},
+	}
+
+	expiry, err := ProjectEntryExpiry([]*PolicySpec{daily, monthly}, entries, stubNow(), false)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := 3, len(expiry); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	for _, expected := range []struct {
+		entry  *Entry
+		expiry time.Time
+	}{
+		{entries[0], mustParseRFC3339("2023-03-31T18:00:00Z")},
+		{entries[1], mustParseRFC3339("2023-01-06T12:00:00Z")},
+		{entries[2], mustParseRFC3339("2023-02-15T00:00:00Z")},
+	} {
+		if _e, _a := expected.expiry, expiry[expected.entry].Truncate(time.Second); !_e.Equal(_a) {
+			t.Fatalf("%s: expected `%v` but got: %v", expected.entry.Raw, _e, _a)
+		}
+	}
+
+	if _, known := expiry[entries[3]]; known {
+		t.Fatalf("expected `%v` but got: %v", false, known)
+	}
+}
+
+func TestProjectEntryExpiryExclusive(t *testing.T) {
+	Now = stubNow
+
+	daily, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	monthly, err := ParsePolicySpecString("monthly", "3m;by=month")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	entries := []*Entry{
+		{
+			Raw:  "entry-0",
+			Time: mustParseRFC3339("2022-12-31T18:00:00Z"),
+		},
+		{
+			Raw:  "entry-1",
+			Time: mustParseRFC3339("2022-12-30T12:00:00Z"),
+		},
+	}
+
+	expiry, err := ProjectEntryExpiry([]*PolicySpec{daily, monthly}, entries, stubNow(), true)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	// once out of the daily range, entry-1 is kept by monthly until entry-0 is
+	if _e, _a := mustParseRFC3339("2023-01-07T18:00:00Z"), expiry[entries[1]].Truncate(time.Second); !_e.Equal(_a) {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
+
+func TestProjectEntryExpiryDecay(t *testing.T) {
+	Now = stubNow
+
+	spec, err := ParsePolicySpecString("exponential", "decay=2")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	entries := []*Entry{
+		{
+			Raw:  "entry-0",
+			Time: mustParseRFC3339("2023-01-01T00:02:03Z"),
+		},
+		{
+			Raw:  "entry-1",
+			Time: mustParseRFC3339("2022-12-31T23:02:03Z"),
+		},
+	}
+
+	expiry, err := ProjectEntryExpiry([]*PolicySpec{spec}, entries, stubNow(), false)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := 2, len(expiry); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	// entry-1 needs 2h of spacing once it is a day old
+	if _e, _a := mustParseRFC3339("2023-01-01T23:02:03Z"), expiry[entries[1]].Truncate(time.Second); !_e.Equal(_a) {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := true, expiry[entries[0]].IsZero(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
@@ -3,23 +3,66 @@ package timepolicy
 import (
 	"fmt"
 	"sort"
+	"time"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
 )
 
 type PolicySelection struct {
 	spec      *PolicySpec
 	evictions EntryWriter
 
-	buckets map[string][]*Entry
+	buckets      map[string][]*Entry
+	entryBuckets map[*Entry]string
+	preferences  map[*Entry]ref.Val
+	matched      int
+
+	// oldest is the oldest entry evaluated; while fill applies, it is also kept
+	// as fill, outside of its bucket.
+	oldest     *Entry
+	fill       *Entry
+	fillBucket string
+}
+
+type PolicySelectionStats struct {
+	// Matched is the number of entries evaluated which were within range and
+	// met any conditions of the policy.
+	Matched int
+
+	// Buckets is the number of buckets currently populated.
+	Buckets int
+
+	// Selected is the number of entries currently selected.
+	Selected int
 }
 
 func NewPolicySelection(spec *PolicySpec, evictions EntryWriter) *PolicySelection {
 	return &PolicySelection{
-		spec:      spec,
-		evictions: evictions,
-		buckets:   map[string][]*Entry{},
+		spec:         spec,
+		evictions:    evictions,
+		buckets:      map[string][]*Entry{},
+		entryBuckets: map[*Entry]string{},
+		preferences:  map[*Entry]ref.Val{},
 	}
 }
 
+func (p *PolicySelection) Spec() *PolicySpec {
+	return p.spec
+}
+
+// EntryBucket returns the bucket key of an entry currently selected by the
+// policy.
+func (p *PolicySelection) EntryBucket(e *Entry) (string, bool) {
+	bucketKey, ok := p.entryBuckets[e]
+	if !ok && e != nil && e == p.fill {
+		return p.fillBucket, true
+	}
+
+	return bucketKey, ok
+}
+
 func (p *PolicySelection) Entries() []*Entry {
 	res := []*Entry{}
 
@@ -27,10 +70,39 @@ func (p *PolicySelection) Entries() []*Entry {
 		res = append(res, bucketEntries...)
 	}
 
+	if p.fillOnly() {
+		res = append(res, p.fill)
+	}
+
 	return res
 }
 
+func (p *PolicySelection) Stats() PolicySelectionStats {
+	stats := PolicySelectionStats{
+		Matched:  p.matched,
+		Buckets:  len(p.buckets),
+		Selected: len(p.entryBuckets),
+	}
+
+	if p.fillOnly() {
+		stats.Selected++
+	}
+
+	return stats
+}
+
 func (p *PolicySelection) EvaluateEntry(e *Entry) (bool, error) {
+	oldest := p.spec.fill && (p.oldest == nil || e.Time.Before(p.oldest.Time))
+	if oldest {
+		// fill only ever keeps the oldest entry evaluated
+		err := p.releaseFill()
+		if err != nil {
+			return false, err
+		}
+
+		p.oldest = e
+	}
+
 	match, err := p.spec.MatchEntry(e)
 	if err != nil {
 		return false, err
@@ -38,6 +110,8 @@ func (p *PolicySelection) EvaluateEntry(e *Entry) (bool, error) {
 		return false, nil
 	}
 
+	p.matched++
+
 	var bucketKey string
 
 	if p.spec.bucket != nil {
@@ -47,14 +121,66 @@ func (p *PolicySelection) EvaluateEntry(e *Entry) (bool, error) {
 		}
 	}
 
+	claimed, err := p.evaluateBucket(e, bucketKey)
+	if err != nil {
+		return false, err
+	} else if !p.spec.fill {
+		return claimed, nil
+	}
+
+	if p.spec.last > 0 && len(p.buckets) >= p.spec.last {
+		err := p.releaseFill()
+		if err != nil {
+			return false, err
+		}
+	} else if oldest {
+		p.fill = e
+		p.fillBucket = bucketKey
+		claimed = true
+	}
+
+	return claimed, nil
+}
+
+// evaluateBucket selects a matching entry within its bucket.
+func (p *PolicySelection) evaluateBucket(e *Entry, bucketKey string) (bool, error) {
 	if p.buckets[bucketKey] == nil {
+		if p.spec.last > 0 && len(p.buckets) >= p.spec.last {
+			evictedKey := p.leastRecentBucket()
+
+			if e.Time.Before(p.buckets[evictedKey][len(p.buckets[evictedKey])-1].Time) {
+				return false, nil
+			}
+
+			err := p.evictBucket(evictedKey)
+			if err != nil {
+				return false, err
+			}
+		}
+
 		p.buckets[bucketKey] = []*Entry{e}
+		p.entryBuckets[e] = bucketKey
 
 		return true, nil
 	}
 
 	bucketEntries := p.buckets[bucketKey]
 
+	if p.spec.edges {
+		return p.evaluateEdges(e, bucketKey, bucketEntries)
+	} else if p.spec.spacing > 0 {
+		// thinned once every entry is known; see Flush
+		nextBucketEntries := append(bucketEntries, e)
+		sort.Slice(nextBucketEntries, func(i, j int) bool {
+			return nextBucketEntries[i].Time.Before(nextBucketEntries[j].Time)
+		})
+
+		p.buckets[bucketKey] = nextBucketEntries
+		p.entryBuckets[e] = bucketKey
+
+		return true, nil
+	}
+
 	if p.spec.max == -1 || len(bucketEntries) < p.spec.max {
 		nextBucketEntries := append(bucketEntries, e)
 		sort.Slice(nextBucketEntries, func(i, j int) bool {
@@ -62,34 +188,303 @@ func (p *PolicySelection) EvaluateEntry(e *Entry) (bool, error) {
 		})
 
 		p.buckets[bucketKey] = nextBucketEntries
+		p.entryBuckets[e] = bucketKey
 
 		return true, nil
-	} else if p.spec.oldest {
-		if e.Time.After(bucketEntries[p.spec.max-1].Time) {
-			return false, nil
+	}
+
+	var evictedIdx int
+
+	for bucketEntryIdx := 1; bucketEntryIdx < len(bucketEntries); bucketEntryIdx++ {
+		keep, err := p.prefers(bucketEntries[bucketEntryIdx], bucketEntries[evictedIdx])
+		if err != nil {
+			return false, err
+		} else if !keep {
+			evictedIdx = bucketEntryIdx
 		}
-	} else {
-		if e.Time.Before(bucketEntries[0].Time) {
-			return false, nil
+	}
+
+	evicted := bucketEntries[evictedIdx]
+
+	keep, err := p.prefers(e, evicted)
+	if err != nil {
+		return false, err
+	} else if !keep {
+		delete(p.preferences, e)
+
+		return false, nil
+	}
+
+	nextBucketEntries := append(append(bucketEntries[0:evictedIdx:evictedIdx], bucketEntries[evictedIdx+1:]...), e)
+	sort.Slice(nextBucketEntries, func(i, j int) bool {
+		return nextBucketEntries[i].Time.Before(nextBucketEntries[j].Time)
+	})
+
+	p.buckets[bucketKey] = nextBucketEntries
+	p.entryBuckets[e] = bucketKey
+
+	delete(p.entryBuckets, evicted)
+	delete(p.preferences, evicted)
+
+	err = p.evict(evicted)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Flush finalizes selections which depend on every entry, such as spacing, and
+// evicts entries which are no longer selected. It may be called again after
+// evaluating more entries.
+func (p *PolicySelection) Flush() error {
+	if p.spec.spacing == 0 {
+		return nil
+	}
+
+	var bucketKeys []string
+
+	for bucketKey := range p.buckets {
+		bucketKeys = append(bucketKeys, bucketKey)
+	}
+
+	sort.Strings(bucketKeys)
+
+	for _, bucketKey := range bucketKeys {
+		var kept, evicted []*Entry
+
+		bucketEntries := p.buckets[bucketKey]
+
+		for bucketEntryIdx := range bucketEntries {
+			// newest first unless oldest is preferred
+			e := bucketEntries[len(bucketEntries)-1-bucketEntryIdx]
+			if p.spec.oldest {
+				e = bucketEntries[bucketEntryIdx]
+			}
+
+			if len(kept) > 0 && absDuration(e.Time.Sub(kept[len(kept)-1].Time)) < p.spec.spacingAt(e.Time) {
+				evicted = append(evicted, e)
+			} else if p.spec.max != -1 && len(kept) == p.spec.max {
+				evicted = append(evicted, e)
+			} else {
+				kept = append(kept, e)
+			}
+		}
+
+		sort.Slice(kept, func(i, j int) bool {
+			return kept[i].Time.Before(kept[j].Time)
+		})
+
+		p.buckets[bucketKey] = kept
+
+		for _, e := range evicted {
+			delete(p.entryBuckets, e)
+
+			err := p.evict(e)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	nextBucketEntries := append(bucketEntries, e)
-	nextBucketEntriesLen := len(nextBucketEntries)
+	return nil
+}
 
+// evaluateEdges keeps the max oldest and max newest entries of a bucket.
+func (p *PolicySelection) evaluateEdges(e *Entry, bucketKey string, bucketEntries []*Entry) (bool, error) {
+	nextBucketEntries := append(bucketEntries, e)
 	sort.Slice(nextBucketEntries, func(i, j int) bool {
 		return nextBucketEntries[i].Time.Before(nextBucketEntries[j].Time)
 	})
 
-	if p.spec.oldest {
-		p.evictions.WriteEntry(nextBucketEntries[nextBucketEntriesLen-1])
-		bucketEntries = nextBucketEntries[0:p.spec.max]
-	} else {
-		p.evictions.WriteEntry(nextBucketEntries[0])
-		bucketEntries = nextBucketEntries[1:nextBucketEntriesLen]
+	if p.spec.max == -1 || len(nextBucketEntries) <= 2*p.spec.max {
+		p.buckets[bucketKey] = nextBucketEntries
+		p.entryBuckets[e] = bucketKey
+
+		return true, nil
+	}
+
+	evicted := nextBucketEntries[p.spec.max]
+	p.buckets[bucketKey] = append(nextBucketEntries[0:p.spec.max:p.spec.max], nextBucketEntries[p.spec.max+1:]...)
+
+	if evicted == e {
+		return false, nil
 	}
 
-	p.buckets[bucketKey] = bucketEntries
+	p.entryBuckets[e] = bucketKey
+
+	delete(p.entryBuckets, evicted)
+
+	err := p.evict(evicted)
+	if err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
+
+// remove drops a selected entry without writing it as an eviction.
+func (p *PolicySelection) remove(e *Entry) {
+	if e == p.fill {
+		p.fill = nil
+	}
+
+	bucketKey, ok := p.entryBuckets[e]
+	if !ok {
+		return
+	}
+
+	bucketEntries := p.buckets[bucketKey]
+
+	for bucketEntryIdx, bucketEntry := range bucketEntries {
+		if bucketEntry == e {
+			bucketEntries = append(bucketEntries[0:bucketEntryIdx:bucketEntryIdx], bucketEntries[bucketEntryIdx+1:]...)
+
+			break
+		}
+	}
+
+	if len(bucketEntries) == 0 {
+		delete(p.buckets, bucketKey)
+	} else {
+		p.buckets[bucketKey] = bucketEntries
+	}
+
+	delete(p.entryBuckets, e)
+	delete(p.preferences, e)
+}
+
+// leastRecentBucket returns the key of the bucket whose newest entry is oldest.
+func (p *PolicySelection) leastRecentBucket() string {
+	var leastKey string
+	var leastTime time.Time
+
+	for bucketKey, bucketEntries := range p.buckets {
+		newest := bucketEntries[len(bucketEntries)-1].Time
+
+		if leastTime.IsZero() || newest.Before(leastTime) || (newest.Equal(leastTime) && bucketKey < leastKey) {
+			leastKey = bucketKey
+			leastTime = newest
+		}
+	}
+
+	return leastKey
+}
+
+func (p *PolicySelection) evictBucket(bucketKey string) error {
+	bucketEntries := p.buckets[bucketKey]
+
+	delete(p.buckets, bucketKey)
+
+	for _, evicted := range bucketEntries {
+		delete(p.entryBuckets, evicted)
+		delete(p.preferences, evicted)
+	}
+
+	for _, evicted := range bucketEntries {
+		err := p.evict(evicted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evict writes an entry which is no longer selected by its bucket, unless it
+// is still kept as fill.
+func (p *PolicySelection) evict(e *Entry) error {
+	if e == p.fill {
+		return nil
+	}
+
+	return p.evictions.WriteEntry(e)
+}
+
+// releaseFill stops keeping the fill entry, evicting it unless it is still
+// selected by its bucket.
+func (p *PolicySelection) releaseFill() error {
+	e := p.fill
+	if e == nil {
+		return nil
+	}
+
+	p.fill = nil
+
+	if _, selected := p.entryBuckets[e]; selected {
+		return nil
+	}
+
+	return p.evictions.WriteEntry(e)
+}
+
+// fillOnly returns whether the fill entry is kept outside of its bucket.
+func (p *PolicySelection) fillOnly() bool {
+	if p.fill == nil {
+		return false
+	}
+
+	_, selected := p.entryBuckets[p.fill]
+
+	return !selected
+}
+
+// prefers returns whether a ranks at least as well as b; first by any prefer
+// expression, then by distance from any at target, and then by time.
+func (p *PolicySelection) prefers(a, b *Entry) (bool, error) {
+	if p.spec.prefer != nil {
+		aVal, err := p.preference(a)
+		if err != nil {
+			return false, err
+		}
+
+		bVal, err := p.preference(b)
+		if err != nil {
+			return false, err
+		}
+
+		if cmp := aVal.(traits.Comparer).Compare(bVal); cmp != types.IntZero {
+			return cmp == types.IntOne, nil
+		}
+	}
+
+	if p.spec.at != nil {
+		enum := p.spec.bucketUnit
+
+		aDistance := absDuration(a.Time.Sub(p.spec.at(enum.start(a.Time))))
+		bDistance := absDuration(b.Time.Sub(p.spec.at(enum.start(b.Time))))
+
+		if aDistance != bDistance {
+			return aDistance < bDistance, nil
+		}
+	}
+
+	if p.spec.oldest {
+		return !a.Time.After(b.Time), nil
+	}
+
+	return !a.Time.Before(b.Time), nil
+}
+
+func (p *PolicySelection) preference(e *Entry) (ref.Val, error) {
+	if val, known := p.preferences[e]; known {
+		return val, nil
+	}
+
+	val, err := p.spec.prefer(e)
+	if err != nil {
+		return nil, fmt.Errorf("prefer: %v", err)
+	}
+
+	p.preferences[e] = val
+
+	return val, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
@@ -1,14 +1,32 @@
 package timepolicy
 
+import "fmt"
+
 type PolicySelectionSet struct {
 	specs     []*PolicySelection
 	evictions EntryWriter
 
-	claims map[*Entry]int
+	claims map[*Entry][]*PolicySelection
+
+	pins      []EntryMatcherFunc
+	pinned    []*Entry
+	pinnedSet map[*Entry]struct{}
+
+	excludes    []EntryMatcherFunc
+	excludedSet map[*Entry]struct{}
+
+	exclusive bool
+}
+
+// PolicyClaim describes a policy which selected an entry.
+type PolicyClaim struct {
+	Spec   *PolicySpec
+	Bucket string
 }
 
 type policySelectionSetEvictionWriter struct {
-	pss *PolicySelectionSet
+	pss    *PolicySelectionSet
+	policy *PolicySelection
 }
 
 var _ EntryWriter = &policySelectionSetEvictionWriter{}
@@ -18,35 +36,118 @@ func (w *policySelectionSetEvictionWriter) EntriesWritten() int64 {
 }
 
 func (w *policySelectionSetEvictionWriter) WriteEntry(e *Entry) error {
-	if w.pss.claims[e] == 1 {
-		delete(w.pss.claims, e)
-		w.pss.evictions.WriteEntry(e)
-	} else {
-		w.pss.claims[e]--
+	claims := w.pss.claims[e]
+
+	for claimIdx, claim := range claims {
+		if claim == w.policy {
+			claims = append(claims[0:claimIdx:claimIdx], claims[claimIdx+1:]...)
+
+			break
+		}
 	}
 
-	return nil
+	if len(claims) > 0 {
+		w.pss.claims[e] = claims
+
+		return nil
+	}
+
+	delete(w.pss.claims, e)
+
+	if w.pss.exclusive {
+		// offer to the policies after the one which evicted it
+		for policyIdx, policy := range w.pss.specs {
+			if policy != w.policy {
+				continue
+			}
+
+			claimed, err := w.pss.evaluateExclusive(e, policyIdx+1)
+			if err != nil {
+				return err
+			} else if claimed {
+				return nil
+			}
+
+			break
+		}
+	}
+
+	return w.pss.evictions.WriteEntry(e)
 }
 
 func NewPolicySelectionSet(specs []*PolicySpec, evictions EntryWriter) *PolicySelectionSet {
 	pss := &PolicySelectionSet{
 		evictions: evictions,
-		claims:    map[*Entry]int{},
-	}
+		claims:    map[*Entry][]*PolicySelection{},
+		pinnedSet: map[*Entry]struct{}{},
 
-	evictionsAggregator := &policySelectionSetEvictionWriter{pss: pss}
+		excludedSet: map[*Entry]struct{}{},
+	}
 
 	for _, spec := range specs {
-		pss.specs = append(pss.specs, NewPolicySelection(spec, evictionsAggregator))
+		evictionsAggregator := &policySelectionSetEvictionWriter{pss: pss}
+		evictionsAggregator.policy = NewPolicySelection(spec, evictionsAggregator)
+
+		pss.specs = append(pss.specs, evictionsAggregator.policy)
 	}
 
 	return pss
 }
 
+// Pin adds a matcher for entries which are always selected. Pinned entries are
+// not evaluated by policies, so they never consume a bucket and are never
+// evicted.
+func (p *PolicySelectionSet) Pin(matcher EntryMatcherFunc) {
+	p.pins = append(p.pins, matcher)
+}
+
+// Exclude adds a matcher for entries which are never selected by policies, so
+// they never consume a bucket. Pins take precedence over exclusions.
+func (p *PolicySelectionSet) Exclude(matcher EntryMatcherFunc) {
+	p.excludes = append(p.excludes, matcher)
+}
+
+// SetExclusive configures whether an entry may only be claimed by one policy.
+// Policies are evaluated in order and an entry claimed by a policy is not
+// eligible for later policies unless it is evicted, so each policy contributes
+// distinct entries.
+func (p *PolicySelectionSet) SetExclusive(exclusive bool) {
+	p.exclusive = exclusive
+}
+
+func (p *PolicySelectionSet) Selections() []*PolicySelection {
+	return p.specs
+}
+
+// Pinned returns the pinned entries, in the order they were evaluated.
+func (p *PolicySelectionSet) Pinned() []*Entry {
+	return p.pinned
+}
+
+func (p *PolicySelectionSet) IsPinned(e *Entry) bool {
+	_, known := p.pinnedSet[e]
+
+	return known
+}
+
+func (p *PolicySelectionSet) IsExcluded(e *Entry) bool {
+	_, known := p.excludedSet[e]
+
+	return known
+}
+
+// Excluded returns the number of entries which were excluded.
+func (p *PolicySelectionSet) Excluded() int {
+	return len(p.excludedSet)
+}
+
+// Entries returns the pinned entries followed by those selected by policies.
 func (p *PolicySelectionSet) Entries() []*Entry {
 	var entries []*Entry
 	uniqEntries := map[*Entry]struct{}{}
 
+	entries = append(entries, p.pinned...)
+
 	for _, policySelection := range p.specs {
 		for _, e := range policySelection.Entries() {
 			if _, known := uniqEntries[e]; known {
@@ -61,19 +162,63 @@ func (p *PolicySelectionSet) Entries() []*Entry {
 	return entries
 }
 
+// EntryClaims returns the policies currently selecting an entry, in the order
+// the policies were configured.
+func (p *PolicySelectionSet) EntryClaims(e *Entry) []PolicyClaim {
+	var claims []PolicyClaim
+
+	for _, policySelection := range p.claims[e] {
+		bucketKey, _ := policySelection.EntryBucket(e)
+
+		claims = append(claims, PolicyClaim{
+			Spec:   policySelection.Spec(),
+			Bucket: bucketKey,
+		})
+	}
+
+	return claims
+}
+
 func (p *PolicySelectionSet) EvaluateEntry(e *Entry) (bool, error) {
-	var claims int
+	for _, pin := range p.pins {
+		pinned, err := pin(e)
+		if err != nil {
+			return false, fmt.Errorf("pin: %v", err)
+		} else if pinned {
+			p.pinned = append(p.pinned, e)
+			p.pinnedSet[e] = struct{}{}
+
+			return true, nil
+		}
+	}
+
+	for _, exclude := range p.excludes {
+		excluded, err := exclude(e)
+		if err != nil {
+			return false, fmt.Errorf("exclude: %v", err)
+		} else if excluded {
+			p.excludedSet[e] = struct{}{}
+
+			return false, nil
+		}
+	}
+
+	if p.exclusive {
+		return p.evaluateExclusive(e, 0)
+	}
+
+	var claims []*PolicySelection
 
 	for _, policySelection := range p.specs {
 		policyClaimed, err := policySelection.EvaluateEntry(e)
 		if err != nil {
-			return false, err
+			return false, fmt.Errorf("policy %s: %v", policySelection.spec.name, err)
 		} else if policyClaimed {
-			claims++
+			claims = append(claims, policySelection)
 		}
 	}
 
-	if claims == 0 {
+	if len(claims) == 0 {
 		return false, nil
 	}
 
@@ -81,3 +226,31 @@ func (p *PolicySelectionSet) EvaluateEntry(e *Entry) (bool, error) {
 
 	return true, nil
 }
+
+// Flush finalizes the selections of every policy, in order. It should be called
+// after evaluating entries and before using the selected entries.
+func (p *PolicySelectionSet) Flush() error {
+	for _, policySelection := range p.specs {
+		err := policySelection.Flush()
+		if err != nil {
+			return fmt.Errorf("policy %s: %v", policySelection.spec.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PolicySelectionSet) evaluateExclusive(e *Entry, policyOffset int) (bool, error) {
+	for _, policySelection := range p.specs[policyOffset:] {
+		policyClaimed, err := policySelection.EvaluateEntry(e)
+		if err != nil {
+			return false, fmt.Errorf("policy %s: %v", policySelection.spec.name, err)
+		} else if policyClaimed {
+			p.claims[e] = []*PolicySelection{policySelection}
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
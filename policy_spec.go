@@ -2,19 +2,43 @@ package timepolicy
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
 )
 
 type PolicySpec struct {
 	raw string
 
-	cutoff    time.Time
-	condition cel.Program
-	bucket    func(e *Entry) (string, error)
-	oldest    bool
-	max       int
+	reference  time.Time
+	cutoff     time.Time
+	cutoffFunc func(reference time.Time) time.Time
+	rangeRaw   string
+
+	condition     cel.Program
+	conditionExpr string
+
+	bucket         func(e *Entry) (string, error)
+	bucketEnum     string
+	bucketUnit     *policySpecBucketEnum
+	bucketExpr     string
+	bucketTimeless bool
+
+	at    func(start time.Time) time.Time
+	atRaw string
+
+	prefer     func(e *Entry) (ref.Val, error)
+	preferExpr string
+
+	oldest  bool
+	edges   bool
+	max     int
+	last    int
+	fill    bool
+	spacing time.Duration
+	decay   float64
 
 	name    string
 	comment string
@@ -24,11 +48,45 @@ func (ps *PolicySpec) String() string {
 	return ps.raw
 }
 
+// Name returns the name qualifier of the policy, or the default name it was
+// parsed with.
+func (ps *PolicySpec) Name() string {
+	return ps.name
+}
+
+// Comment returns the text following // in the policy, if any.
+func (ps *PolicySpec) Comment() string {
+	return ps.comment
+}
+
+// Cutoff returns the oldest time an entry may have to match the policy, or the
+// zero time when the policy has no range.
+func (ps *PolicySpec) Cutoff() time.Time {
+	return ps.cutoff
+}
+
+// Resolve returns a copy of the policy with its range relative to reference
+// instead of the time it was parsed.
+func (ps *PolicySpec) Resolve(reference time.Time) *PolicySpec {
+	resolved := *ps
+	resolved.reference = reference
+
+	if ps.cutoffFunc != nil {
+		resolved.cutoff = ps.cutoffFunc(reference)
+	}
+
+	return &resolved
+}
+
 func (ps *PolicySpec) MatchEntry(e *Entry) (bool, error) {
 	if e.Time.Before(ps.cutoff) {
 		return false, nil
 	}
 
+	return ps.matchCondition(e)
+}
+
+func (ps *PolicySpec) matchCondition(e *Entry) (bool, error) {
 	if ps.condition != nil {
 		val, _, err := e.Eval(ps.condition)
 		if err != nil {
@@ -40,3 +98,39 @@ func (ps *PolicySpec) MatchEntry(e *Entry) (bool, error) {
 
 	return true, nil
 }
+
+// spacingAt returns the minimum spacing of entries at t. With decay, spacing
+// grows by the decay factor for each tier of age, and each tier is 24 times the
+// spacing of the tier long, so the number of entries per tier is similar.
+func (ps *PolicySpec) spacingAt(t time.Time) time.Duration {
+	if ps.decay == 0 {
+		return ps.spacing
+	}
+
+	age := ps.reference.Sub(t)
+	if age < 0 {
+		age = 0
+	}
+
+	return time.Duration(float64(ps.spacing) * math.Pow(ps.decay, float64(ps.decayTier(age))))
+}
+
+// decayTier returns the tier of decay for an entry of age, starting at 0.
+func (ps *PolicySpec) decayTier(age time.Duration) int {
+	tierLength := float64(24 * ps.spacing)
+
+	return int(math.Floor(math.Log(float64(age)*(ps.decay-1)/tierLength+1) / math.Log(ps.decay)))
+}
+
+// decayTierAge returns the age at which entries enter a tier of decay.
+func (ps *PolicySpec) decayTierAge(tier int) time.Duration {
+	tierLength := float64(24 * ps.spacing)
+	age := time.Duration(math.Ceil(tierLength * (math.Pow(ps.decay, float64(tier)) - 1) / (ps.decay - 1)))
+
+	for ps.decayTier(age) < tier {
+		// rounding
+		age++
+	}
+
+	return age
+}
@@ -3,6 +3,7 @@ package timepolicy
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -10,52 +11,127 @@ import (
 
 	"github.com/dpb587/timepolicy/internal"
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
 )
 
 var (
 	policySpecQualifierSplit = regexp.MustCompile(`\s*;\s*`)
-	policySpecBucketEnums    = map[string]func(e *Entry) (string, error){
-		"year": func(e *Entry) (string, error) {
-			return e.Time.Format("2006"), nil
+	policySpecBucketEnums    = map[string]policySpecBucketEnum{
+		"year": {
+			key: func(t time.Time) string {
+				return t.Format("2006")
+			},
+			start: func(t time.Time) time.Time {
+				return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+			},
+			next: func(start time.Time) time.Time {
+				return start.AddDate(1, 0, 0)
+			},
+			period: 365 * 24 * time.Hour,
 		},
-		"month": func(e *Entry) (string, error) {
-			return e.Time.Format("2006-01"), nil
+		"month": {
+			key: func(t time.Time) string {
+				return t.Format("2006-01")
+			},
+			start: func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+			},
+			next: func(start time.Time) time.Time {
+				return start.AddDate(0, 1, 0)
+			},
+			period: 28 * 24 * time.Hour,
+			within: []string{"year"},
 		},
-		"day": func(e *Entry) (string, error) {
-			return e.Time.Format("2006-01-02"), nil
+		"week": {
+			key: func(t time.Time) string {
+				year, week := t.ISOWeek()
+
+				return fmt.Sprintf("%04d-W%02d", year, week)
+			},
+			start: func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+			},
+			next: func(start time.Time) time.Time {
+				return start.AddDate(0, 0, 7)
+			},
+			period: 7 * 24 * time.Hour,
 		},
-		"hour": func(e *Entry) (string, error) {
-			return e.Time.Format("2006-01-02T15"), nil
+		"day": {
+			key: func(t time.Time) string {
+				return t.Format("2006-01-02")
+			},
+			start: func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+			},
+			next: func(start time.Time) time.Time {
+				return start.AddDate(0, 0, 1)
+			},
+			period: 24 * time.Hour,
+			within: []string{"week", "month", "year"},
+		},
+		"hour": {
+			key: func(t time.Time) string {
+				return t.Format("2006-01-02T15")
+			},
+			start: func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+			},
+			next: func(start time.Time) time.Time {
+				return start.Add(time.Hour)
+			},
+			period: time.Hour,
+			within: []string{"day", "week", "month", "year"},
 		},
 	}
-	policySpecRangeUnits = map[byte]func(n int64) time.Time{
-		's': func(n int64) time.Time {
-			return Now().Add(-1 * time.Second * time.Duration(n))
+	policySpecRangeUnits = map[byte]func(reference time.Time, n int64) time.Time{
+		's': func(reference time.Time, n int64) time.Time {
+			return reference.Add(-1 * time.Second * time.Duration(n))
+		},
+		'h': func(reference time.Time, n int64) time.Time {
+			return reference.Add(-1 * time.Hour * time.Duration(n))
 		},
-		'h': func(n int64) time.Time {
-			return Now().Add(-1 * time.Hour * time.Duration(n))
+		'd': func(reference time.Time, n int64) time.Time {
+			return reference.AddDate(0, 0, int(-1*n))
 		},
-		'd': func(n int64) time.Time {
-			return Now().AddDate(0, 0, int(-1*n))
+		'w': func(reference time.Time, n int64) time.Time {
+			return reference.AddDate(0, 0, int(-7*n))
 		},
-		'm': func(n int64) time.Time {
-			return Now().AddDate(0, int(-1*n), 0)
+		'm': func(reference time.Time, n int64) time.Time {
+			return reference.AddDate(0, int(-1*n), 0)
 		},
-		'y': func(n int64) time.Time {
-			return Now().AddDate(int(-1*n), 0, 0)
+		'y': func(reference time.Time, n int64) time.Time {
+			return reference.AddDate(int(-1*n), 0, 0)
 		},
 	}
 )
 
+type policySpecBucketEnum struct {
+	key func(t time.Time) string
+
+	// start returns the start of the bucket containing t.
+	start func(t time.Time) time.Time
+
+	// next returns the start of the bucket following the bucket of start.
+	next func(start time.Time) time.Time
+
+	// period is the shortest duration of a bucket.
+	period time.Duration
+
+	// within lists the enums whose buckets fully contain each bucket.
+	within []string
+}
+
 var commentSplit = regexp.MustCompile(`\s+//\s+`)
+var policySpecNameValid = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
 
 func ParsePolicySpecString(defaultName string, raw string) (*PolicySpec, error) {
 	var err error
 
 	ps := &PolicySpec{
-		raw:  raw,
-		name: defaultName,
-		max:  -1,
+		raw:       raw,
+		reference: Now(),
+		name:      defaultName,
+		max:       -1,
 	}
 
 	rawSplit := commentSplit.Split(raw, 2)
@@ -71,11 +147,14 @@ func ParsePolicySpecString(defaultName string, raw string) (*PolicySpec, error)
 
 		if rawPieceIdx == 0 {
 			if len(rawPieceSplit) == 1 {
-				ps.cutoff, err = parsePolicySpecRangeCutoff(rawPieceSplit[0])
+				ps.cutoffFunc, err = ParseRange(rawPieceSplit[0])
 				if err != nil {
 					return nil, fmt.Errorf("parsing range: %v", err)
 				}
 
+				ps.cutoff = ps.cutoffFunc(ps.reference)
+				ps.rangeRaw = rawPieceSplit[0]
+
 				continue
 			}
 
@@ -107,14 +186,35 @@ func ParsePolicySpecString(defaultName string, raw string) (*PolicySpec, error)
 			}
 
 			ps.condition = prg
+			ps.conditionExpr = rawPieceSplit[1]
+
+			continue
 		case "by":
 			if len(rawPieceSplit) != 2 {
 				return nil, errors.New("parsing qualifier: parsing by: missing value")
 			}
 
-			enumFunc, ok := policySpecBucketEnums[rawPieceSplit[1]]
+			enum, ok := policySpecBucketEnums[rawPieceSplit[1]]
+			if !ok {
+				enum, ok, err = parsePolicySpecOffsetBucket(rawPieceSplit[1])
+				if err != nil {
+					return nil, fmt.Errorf("parsing qualifier: parsing by: %v", err)
+				}
+			}
+
+			if !ok {
+				enum, ok, err = parsePolicySpecDurationBucket(rawPieceSplit[1])
+				if err != nil {
+					return nil, fmt.Errorf("parsing qualifier: parsing by: %v", err)
+				}
+			}
+
 			if ok {
-				ps.bucket = enumFunc
+				ps.bucket = func(e *Entry) (string, error) {
+					return enum.key(e.Time), nil
+				}
+				ps.bucketEnum = rawPieceSplit[1]
+				ps.bucketUnit = &enum
 
 				continue
 			}
@@ -131,6 +231,9 @@ func ParsePolicySpecString(defaultName string, raw string) (*PolicySpec, error)
 				return nil, fmt.Errorf("parsing qualifier: parsing by: installing: %v", err)
 			}
 
+			ps.bucketExpr = rawPieceSplit[1]
+			ps.bucketTimeless = !internal.ExpressionReferences(ast, "ts")
+
 			switch ast.OutputType() {
 			case cel.StringType:
 				ps.bucket = func(e *Entry) (string, error) {
@@ -179,21 +282,66 @@ func ParsePolicySpecString(defaultName string, raw string) (*PolicySpec, error)
 			}
 
 			continue
-		case "oldest", "newest":
+		case "prefer":
+			if len(rawPieceSplit) != 2 {
+				return nil, errors.New("parsing qualifier: parsing prefer: missing value")
+			}
+
+			ast, issues := internal.InputExpressionEnv.Compile(rawPieceSplit[1])
+			if err := issues.Err(); err != nil {
+				return nil, fmt.Errorf("parsing qualifier: parsing prefer: compiling: %v", err)
+			} else if !ast.IsChecked() {
+				return nil, errors.New("parsing qualifier: parsing prefer: expression must have a deterministic result")
+			}
+
+			switch ast.OutputType() {
+			case cel.BoolType, cel.DoubleType, cel.IntType, cel.StringType, cel.UintType:
+				// comparable
+			default:
+				return nil, errors.New("parsing qualifier: parsing prefer: expression must have a bool, number, or string result")
+			}
+
+			prg, err := internal.InputExpressionEnv.Program(ast)
+			if err != nil {
+				return nil, fmt.Errorf("parsing qualifier: parsing prefer: installing: %v", err)
+			}
+
+			ps.prefer = func(e *Entry) (ref.Val, error) {
+				val, _, err := e.Eval(prg)
+				if err != nil {
+					return nil, err
+				}
+
+				return val, nil
+			}
+			ps.preferExpr = rawPieceSplit[1]
+
+			continue
+		case "at":
+			if len(rawPieceSplit) != 2 {
+				return nil, errors.New("parsing qualifier: parsing at: missing value")
+			}
+
+			// parsed once the bucket is known
+			ps.atRaw = rawPieceSplit[1]
+
+			continue
+		case "oldest", "newest", "edges", "both":
 			if len(rawPieceSplit) == 2 {
 				return nil, fmt.Errorf("parsing qualifier: parsing %s: unexpected value", rawPieceSplit[0])
 			}
 
-			if rawPieceSplit[0] == "oldest" {
-				if _, known := uniqQualifiers["newest"]; known {
-					return nil, fmt.Errorf("parsing qualifier: parsing %s: either oldest or newest may be configured only once", rawPieceSplit[0])
+			for _, direction := range []string{"oldest", "newest", "edges", "both"} {
+				if _, known := uniqQualifiers[direction]; known && direction != rawPieceSplit[0] {
+					return nil, fmt.Errorf("parsing qualifier: parsing %s: only one of oldest, newest, or edges may be configured", rawPieceSplit[0])
 				}
+			}
 
+			switch rawPieceSplit[0] {
+			case "oldest":
 				ps.oldest = true
-			} else {
-				if _, known := uniqQualifiers["oldest"]; known {
-					return nil, fmt.Errorf("parsing qualifier: parsing %s: either oldest or newest may be configured only once", rawPieceSplit[0])
-				}
+			case "edges", "both":
+				ps.edges = true
 			}
 
 			continue
@@ -211,14 +359,104 @@ func ParsePolicySpecString(defaultName string, raw string) (*PolicySpec, error)
 
 			ps.max = int(maxInt)
 
+			continue
+		case "spacing":
+			if len(rawPieceSplit) != 2 {
+				return nil, errors.New("parsing qualifier: parsing spacing: missing value")
+			}
+
+			spacing, err := internal.ParseDuration(rawPieceSplit[1])
+			if err != nil {
+				return nil, fmt.Errorf("parsing qualifier: parsing spacing: %v", err)
+			} else if spacing <= 0 {
+				return nil, errors.New("parsing qualifier: parsing spacing: duration must be greater than 0")
+			}
+
+			ps.spacing = spacing
+
+			continue
+		case "decay":
+			if len(rawPieceSplit) != 2 {
+				return nil, errors.New("parsing qualifier: parsing decay: missing value")
+			}
+
+			decay, err := strconv.ParseFloat(rawPieceSplit[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing qualifier: parsing decay: parsing number: %v", err)
+			} else if !(decay > 1) || math.IsInf(decay, 0) {
+				return nil, errors.New("parsing qualifier: parsing decay: number must be greater than 1")
+			}
+
+			ps.decay = decay
+
+			continue
+		case "last":
+			if len(rawPieceSplit) != 2 {
+				return nil, errors.New("parsing qualifier: parsing last: missing value")
+			}
+
+			lastInt, err := strconv.ParseInt(rawPieceSplit[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing qualifier: parsing last: parsing number: %v", err)
+			} else if lastInt < 1 {
+				return nil, fmt.Errorf("parsing qualifier: parsing last: number must be greater than 0")
+			}
+
+			ps.last = int(lastInt)
+
+			continue
+		case "fill":
+			if len(rawPieceSplit) == 2 {
+				return nil, errors.New("parsing qualifier: parsing fill: unexpected value")
+			}
+
+			ps.fill = true
+
+			continue
+		case "name":
+			if len(rawPieceSplit) != 2 {
+				return nil, errors.New("parsing qualifier: parsing name: missing value")
+			} else if !policySpecNameValid.MatchString(rawPieceSplit[1]) {
+				return nil, errors.New("parsing qualifier: parsing name: must start with a letter or number and contain only letters, numbers, dots, dashes, or underscores")
+			}
+
+			ps.name = rawPieceSplit[1]
+
 			continue
 		}
 
 		return nil, fmt.Errorf("parsing qualifier: unexpected input: %s", rawPiece)
 	}
 
+	if ps.decay > 0 && ps.spacing == 0 {
+		ps.spacing = time.Hour
+	}
+
+	if ps.edges && (ps.prefer != nil || ps.atRaw != "") {
+		return nil, errors.New("parsing qualifier: parsing edges: cannot be combined with prefer or at")
+	} else if ps.spacing > 0 && (ps.edges || ps.prefer != nil || ps.atRaw != "") {
+		return nil, errors.New("parsing qualifier: parsing spacing: spacing and decay cannot be combined with edges, prefer, or at")
+	}
+
+	if ps.last > 0 && ps.bucket == nil {
+		return nil, errors.New("parsing qualifier: parsing last: requires by")
+	} else if ps.fill && ps.bucket == nil {
+		return nil, errors.New("parsing qualifier: parsing fill: requires by")
+	}
+
+	if ps.atRaw != "" {
+		if strings.Contains(ps.bucketEnum, "@") {
+			return nil, errors.New("parsing qualifier: parsing at: not supported with bucket offsets or anchors")
+		}
+
+		ps.at, err = parsePolicySpecAt(ps.bucketEnum, ps.atRaw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing qualifier: parsing at: %v", err)
+		}
+	}
+
 	if _, known := uniqQualifiers["max"]; !known {
-		if ps.bucket != nil {
+		if ps.bucket != nil && ps.spacing == 0 {
 			ps.max = 1
 		}
 	}
@@ -226,21 +464,299 @@ func ParsePolicySpecString(defaultName string, raw string) (*PolicySpec, error)
 	return ps, nil
 }
 
-func parsePolicySpecRangeCutoff(value string) (time.Time, error) {
+// ParseRange parses a time range in the format of {INT}{UNIT}, such as 7d, and
+// returns a function to compute the start of the range ending at a reference.
+func ParseRange(value string) (func(reference time.Time) time.Time, error) {
 	valueLen := len(value)
 	if valueLen < 2 {
-		return time.Time{}, errors.New("invalid value: expected `{INT}{UNIT}`")
+		return nil, errors.New("invalid value: expected `{INT}{UNIT}`")
 	}
 
 	valueUnitFunc, ok := policySpecRangeUnits[value[valueLen-1]]
 	if !ok {
-		return time.Time{}, fmt.Errorf("parsing unit: %s", string(value[valueLen-1]))
+		return nil, fmt.Errorf("parsing unit: %s", string(value[valueLen-1]))
 	}
 
 	valueNumber, err := strconv.ParseInt(value[0:(valueLen-1)], 10, 64)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("parsing number: %v", err)
+		return nil, fmt.Errorf("parsing number: %v", err)
+	}
+
+	return func(reference time.Time) time.Time {
+		return valueUnitFunc(reference, valueNumber)
+	}, nil
+}
+
+var (
+	policySpecAtClock    = regexp.MustCompile(`^(?:(\S+)\s+)?(\d{1,2}):(\d{2})$`)
+	policySpecAtMinute   = regexp.MustCompile(`^:(\d{2})$`)
+	policySpecAtWeekdays = map[string]int{"mon": 0, "tue": 1, "wed": 2, "thu": 3, "fri": 4, "sat": 5, "sun": 6}
+)
+
+// parsePolicySpecAt parses a target within buckets of an enum and returns a
+// function to compute the target from the start of a bucket. Values are in the
+// format of [{DAY} ]{HH}:{MM}, where DAY is a weekday (week), day of month
+// (month), or {MM}-{DD} (year); or :{MM} for hour.
+func parsePolicySpecAt(bucketEnum string, value string) (func(start time.Time) time.Time, error) {
+	if bucketEnum == "hour" {
+		match := policySpecAtMinute.FindStringSubmatch(value)
+		if match == nil {
+			return nil, errors.New("expected `:{MM}` for by=hour")
+		}
+
+		minute, _ := strconv.Atoi(match[1])
+		if minute > 59 {
+			return nil, fmt.Errorf("invalid minute: %d", minute)
+		}
+
+		return func(start time.Time) time.Time {
+			return start.Add(time.Duration(minute) * time.Minute)
+		}, nil
+	}
+
+	match := policySpecAtClock.FindStringSubmatch(value)
+	if match == nil {
+		return nil, errors.New("expected `[{DAY} ]{HH}:{MM}`")
+	}
+
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+
+	if hour > 23 {
+		return nil, fmt.Errorf("invalid hour: %d", hour)
+	} else if minute > 59 {
+		return nil, fmt.Errorf("invalid minute: %d", minute)
+	}
+
+	day := match[1]
+
+	switch bucketEnum {
+	case "day":
+		if day != "" {
+			return nil, errors.New("day is not supported for by=day")
+		}
+
+		return func(start time.Time) time.Time {
+			return time.Date(start.Year(), start.Month(), start.Day(), hour, minute, 0, 0, start.Location())
+		}, nil
+	case "week":
+		var weekday int
+
+		if day != "" {
+			var ok bool
+
+			weekday, ok = policySpecAtWeekdays[strings.ToLower(day)]
+			if !ok {
+				return nil, fmt.Errorf("invalid weekday: %s", day)
+			}
+		}
+
+		return func(start time.Time) time.Time {
+			return time.Date(start.Year(), start.Month(), start.Day()+weekday, hour, minute, 0, 0, start.Location())
+		}, nil
+	case "month":
+		dayOfMonth := 1
+
+		if day != "" {
+			var err error
+
+			dayOfMonth, err = strconv.Atoi(day)
+			if err != nil || dayOfMonth < 1 || dayOfMonth > 31 {
+				return nil, fmt.Errorf("invalid day of month: %s", day)
+			}
+		}
+
+		return func(start time.Time) time.Time {
+			lastDay := time.Date(start.Year(), start.Month()+1, 0, 0, 0, 0, 0, start.Location()).Day()
+			if dayOfMonth < lastDay {
+				lastDay = dayOfMonth
+			}
+
+			return time.Date(start.Year(), start.Month(), lastDay, hour, minute, 0, 0, start.Location())
+		}, nil
+	case "year":
+		month, dayOfMonth := 1, 1
+
+		if day != "" {
+			parsed, err := time.Parse("01-02", day)
+			if err != nil {
+				return nil, fmt.Errorf("invalid month and day: %s", day)
+			}
+
+			month, dayOfMonth = int(parsed.Month()), parsed.Day()
+		}
+
+		return func(start time.Time) time.Time {
+			return time.Date(start.Year(), time.Month(month), dayOfMonth, hour, minute, 0, 0, start.Location())
+		}, nil
+	}
+
+	return nil, errors.New("requires by=year, month, week, day, or hour")
+}
+
+// parsePolicySpecDurationBucket parses buckets of a fixed duration in the format
+// of {DURATION}[@{ANCHOR}], such as 6h or 10d@2023-01-01T00:00:00Z. Buckets
+// start at multiples of the duration from the anchor (default is the Unix
+// epoch) and keys are the UTC start of the bucket. The value is not a duration
+// bucket when it is not a duration.
+func parsePolicySpecDurationBucket(value string) (policySpecBucketEnum, bool, error) {
+	valueSplit := strings.SplitN(value, "@", 2)
+
+	period, err := internal.ParseDuration(valueSplit[0])
+	if err != nil || period <= 0 {
+		return policySpecBucketEnum{}, false, nil
+	} else if period < time.Second {
+		return policySpecBucketEnum{}, false, errors.New("duration must be at least 1s")
+	}
+
+	anchor := time.Unix(0, 0)
+
+	if len(valueSplit) == 2 {
+		anchor, err = time.Parse(time.RFC3339, valueSplit[1])
+		if err != nil {
+			return policySpecBucketEnum{}, false, fmt.Errorf("parsing anchor: %v", err)
+		}
+	}
+
+	start := func(t time.Time) time.Time {
+		offset := t.Sub(anchor) % period
+		if offset < 0 {
+			offset += period
+		}
+
+		return t.Add(-offset).UTC()
+	}
+
+	return policySpecBucketEnum{
+		key: func(t time.Time) string {
+			return start(t).Format(time.RFC3339)
+		},
+		start: start,
+		next: func(start time.Time) time.Time {
+			return start.Add(period)
+		},
+		period: period,
+	}, true, nil
+}
+
+var policySpecOffsetClock = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+
+// parsePolicySpecOffsetBucket parses a bucket enum with its boundaries offset
+// from the calendar in the format of {ENUM}@{OFFSET}, such as day@04:00. Offsets
+// are :{MM} for hour, {HH}:{MM} for day, {WEEKDAY}[ {HH}:{MM}] for week,
+// {DAY}[ {HH}:{MM}] for month, and {MM}-{DD}[ {HH}:{MM}] for year. Days must be
+// at most 28 so every bucket has a boundary. The value is not an offset bucket
+// when it does not start with an enum.
+func parsePolicySpecOffsetBucket(value string) (policySpecBucketEnum, bool, error) {
+	valueSplit := strings.SplitN(value, "@", 2)
+	if len(valueSplit) != 2 {
+		return policySpecBucketEnum{}, false, nil
+	}
+
+	base, ok := policySpecBucketEnums[valueSplit[0]]
+	if !ok {
+		return policySpecBucketEnum{}, false, nil
+	}
+
+	var months, days int
+	var clock time.Duration
+
+	offsetFields := strings.Fields(valueSplit[1])
+	if len(offsetFields) == 0 || len(offsetFields) > 2 {
+		return policySpecBucketEnum{}, false, fmt.Errorf("parsing offset: invalid value: %s", valueSplit[1])
+	}
+
+	parseClock := func(v string) error {
+		match := policySpecOffsetClock.FindStringSubmatch(v)
+		if match == nil {
+			return fmt.Errorf("invalid time: %s", v)
+		}
+
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+
+		if hour > 23 || minute > 59 {
+			return fmt.Errorf("invalid time: %s", v)
+		}
+
+		clock = time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+
+		return nil
+	}
+
+	var err error
+
+	switch valueSplit[0] {
+	case "hour":
+		match := policySpecAtMinute.FindStringSubmatch(valueSplit[1])
+		if match == nil {
+			return policySpecBucketEnum{}, false, errors.New("parsing offset: expected `:{MM}` for hour")
+		}
+
+		minute, _ := strconv.Atoi(match[1])
+		if minute > 59 {
+			return policySpecBucketEnum{}, false, fmt.Errorf("parsing offset: invalid minute: %d", minute)
+		}
+
+		clock = time.Duration(minute) * time.Minute
+	case "day":
+		if len(offsetFields) != 1 {
+			return policySpecBucketEnum{}, false, errors.New("parsing offset: expected `{HH}:{MM}` for day")
+		}
+
+		err = parseClock(offsetFields[0])
+	default:
+		switch valueSplit[0] {
+		case "week":
+			weekday, known := policySpecAtWeekdays[strings.ToLower(offsetFields[0])]
+			if !known {
+				return policySpecBucketEnum{}, false, fmt.Errorf("parsing offset: invalid weekday: %s", offsetFields[0])
+			}
+
+			days = weekday
+		case "month":
+			day, err := strconv.Atoi(offsetFields[0])
+			if err != nil || day < 1 || day > 28 {
+				return policySpecBucketEnum{}, false, fmt.Errorf("parsing offset: invalid day of month (1-28): %s", offsetFields[0])
+			}
+
+			days = day - 1
+		case "year":
+			parsed, err := time.Parse("01-02", offsetFields[0])
+			if err != nil || parsed.Day() > 28 {
+				return policySpecBucketEnum{}, false, fmt.Errorf("parsing offset: invalid month and day (MM-DD, day 1-28): %s", offsetFields[0])
+			}
+
+			months, days = int(parsed.Month())-1, parsed.Day()-1
+		}
+
+		if len(offsetFields) == 2 {
+			err = parseClock(offsetFields[1])
+		}
+	}
+
+	if err != nil {
+		return policySpecBucketEnum{}, false, fmt.Errorf("parsing offset: %v", err)
+	}
+
+	back := func(t time.Time) time.Time {
+		return t.Add(-clock).AddDate(0, -months, -days)
+	}
+
+	forward := func(t time.Time) time.Time {
+		return t.AddDate(0, months, days).Add(clock)
 	}
 
-	return valueUnitFunc(valueNumber), nil
+	return policySpecBucketEnum{
+		key: func(t time.Time) string {
+			return base.key(back(t))
+		},
+		start: func(t time.Time) time.Time {
+			return forward(base.start(back(t)))
+		},
+		next: func(start time.Time) time.Time {
+			return forward(base.next(back(start)))
+		},
+		period: base.period,
+	}, true, nil
 }
@@ -2,6 +2,7 @@ package timepolicy
 
 import (
 	"bytes"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -16,6 +17,10 @@ func mustParseRFC3339(v string) time.Time {
 	return t
 }
 
+func parseRFC3339(v string) (time.Time, error) {
+	return time.Parse(time.RFC3339, v)
+}
+
 func stubNow() time.Time {
 	return mustParseRFC3339("2023-01-01T01:02:03Z")
 }
@@ -195,3 +200,741 @@ func TestBasicOldest(t *testing.T) {
 		t.Fatalf("expected `%v` but got: %v", _e, _a)
 	}
 }
+
+func TestSetEntryClaims(t *testing.T) {
+	Now = stubNow
+
+	daily, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	monthly, err := ParsePolicySpecString("monthly", "1y;by=month")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	deferredEvictions := bytes.NewBuffer(nil)
+	deferredEvictionsWriter := NewEntryWriter(deferredEvictions)
+
+	pss := NewPolicySelectionSet([]*PolicySpec{daily, monthly}, deferredEvictionsWriter)
+
+	entry0 := &Entry{
+		Raw:  "entry-0",
+		Time: mustParseRFC3339("2022-12-30T18:00:00Z"),
+	}
+
+	entry1 := &Entry{
+		Raw:  "entry-1",
+		Time: mustParseRFC3339("2022-12-31T18:00:00Z"),
+	}
+
+	for _, e := range []*Entry{entry0, entry1} {
+		if _, err := pss.EvaluateEntry(e); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if _e, _a := "", deferredEvictions.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	claims0 := pss.EntryClaims(entry0)
+	if _e, _a := 1, len(claims0); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := daily, claims0[0].Spec; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "2022-12-30", claims0[0].Bucket; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	claims1 := pss.EntryClaims(entry1)
+	if _e, _a := 2, len(claims1); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := monthly, claims1[1].Spec; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "2022-12", claims1[1].Bucket; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	{ // newer entry replaces both claims of entry-1
+		if _, err := pss.EvaluateEntry(&Entry{
+			Raw:  "entry-2",
+			Time: mustParseRFC3339("2022-12-31T20:00:00Z"),
+		}); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if _e, _a := "entry-1\n", deferredEvictions.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 0, len(pss.EntryClaims(entry1)); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
+
+func TestNameQualifier(t *testing.T) {
+	spec, err := ParsePolicySpecString("policy-0", "1y;by=month;name=monthly // keep one per month")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "monthly", spec.Name(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "keep one per month", spec.Comment(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 1, spec.max; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	spec, err = ParsePolicySpecString("policy-0", "1y")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "policy-0", spec.Name(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	_, err = ParsePolicySpecString("policy-0", "1y;name=two words")
+	if err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
+
+func TestSetPin(t *testing.T) {
+	Now = stubNow
+
+	daily, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	evictions := bytes.NewBuffer(nil)
+
+	pss := NewPolicySelectionSet([]*PolicySpec{daily}, NewEntryWriter(evictions))
+	pss.Pin(NewKeyEntryMatcher([]string{"hold"}))
+
+	entry0 := &Entry{
+		Raw:    "2022-12-31T06:00:00Z hold",
+		Fields: []string{"2022-12-31T06:00:00Z", "hold"},
+		Time:   mustParseRFC3339("2022-12-31T06:00:00Z"),
+	}
+
+	entry1 := &Entry{
+		Raw:    "2022-12-31T18:00:00Z",
+		Fields: []string{"2022-12-31T18:00:00Z"},
+		Time:   mustParseRFC3339("2022-12-31T18:00:00Z"),
+	}
+
+	for _, e := range []*Entry{entry0, entry1} {
+		if _, err := pss.EvaluateEntry(e); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if _e, _a := "", evictions.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := true, pss.IsPinned(entry0); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 0, len(pss.EntryClaims(entry0)); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 2, len(pss.Entries()); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+}
+
+func TestSetExclude(t *testing.T) {
+	Now = stubNow
+
+	daily, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	exclude, err := ParsePolicySpecString("failed", `if=fields[1] == "failed"`)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	excludeMatcher, err := NewExclusionEntryMatcher(exclude)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	pss := NewPolicySelectionSet([]*PolicySpec{daily}, NewDiscardEntryWriter())
+	pss.Exclude(excludeMatcher)
+
+	entry0 := &Entry{
+		Raw:    "2022-12-31T06:00:00Z ok",
+		Fields: []string{"2022-12-31T06:00:00Z", "ok"},
+		Time:   mustParseRFC3339("2022-12-31T06:00:00Z"),
+	}
+
+	entry1 := &Entry{
+		Raw:    "2022-12-31T18:00:00Z failed",
+		Fields: []string{"2022-12-31T18:00:00Z", "failed"},
+		Time:   mustParseRFC3339("2022-12-31T18:00:00Z"),
+	}
+
+	for _, e := range []*Entry{entry0, entry1} {
+		if _, err := pss.EvaluateEntry(e); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if _e, _a := true, pss.IsExcluded(entry1); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 1, len(pss.Entries()); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := entry0, pss.Entries()[0]; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	_, err = NewExclusionEntryMatcher(daily)
+	if err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
+
+func TestPrefer(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw      string
+		expected string
+	}{
+		{raw: "7d;by=day;prefer=int(fields[1])", expected: "2022-12-31T12:00:00Z"},
+		{raw: `7d;by=day;prefer=fields[2] == "complete"`, expected: "2022-12-31T06:00:00Z"},
+		{raw: "7d;by=day;prefer=-int(fields[1]);oldest", expected: "2022-12-31T00:00:00Z"},
+	} {
+		t.Run(tc.raw, func(t *testing.T) {
+			spec, err := ParsePolicySpecString("policy-0", tc.raw)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			ps := NewPolicySelection(spec, NewDiscardEntryWriter())
+
+			for _, raw := range []string{
+				"2022-12-31T00:00:00Z 100 complete",
+				"2022-12-31T06:00:00Z 200 complete",
+				"2022-12-31T12:00:00Z 300 partial",
+				"2022-12-31T18:00:00Z 100 partial",
+			} {
+				fields := strings.Fields(raw)
+
+				_, err := ps.EvaluateEntry(&Entry{
+					Raw:    raw,
+					Fields: fields,
+					Time:   mustParseRFC3339(fields[0]),
+				})
+				if err != nil {
+					t.Fatalf("expected `nil` but got: %v", err)
+				}
+			}
+
+			entries := ps.Entries()
+			if _e, _a := 1, len(entries); _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			} else if _e, _a := tc.expected, entries[0].Fields[0]; _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			}
+		})
+	}
+}
+
+func TestAt(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw      string
+		entries  []string
+		expected []string
+	}{
+		{
+			raw:      "2w;by=week;at=sun 02:00",
+			entries:  []string{"2022-12-26T02:00:00Z", "2022-12-31T02:00:00Z", "2023-01-01T01:00:00Z"},
+			expected: []string{"2023-01-01T01:00:00Z"},
+		},
+		{
+			raw:      "7d;by=day;at=00:00",
+			entries:  []string{"2022-12-30T23:30:00Z", "2022-12-31T00:30:00Z", "2022-12-31T23:00:00Z"},
+			expected: []string{"2022-12-30T23:30:00Z", "2022-12-31T00:30:00Z"},
+		},
+		{
+			raw:      "1y;by=month;at=31 00:00",
+			entries:  []string{"2022-02-01T00:00:00Z", "2022-02-28T06:00:00Z", "2022-03-01T00:00:00Z"},
+			expected: []string{"2022-02-28T06:00:00Z", "2022-03-01T00:00:00Z"},
+		},
+	} {
+		t.Run(tc.raw, func(t *testing.T) {
+			spec, err := ParsePolicySpecString("policy-0", tc.raw)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			ps := NewPolicySelection(spec, NewDiscardEntryWriter())
+
+			for _, raw := range tc.entries {
+				if _, err := ps.EvaluateEntry(&Entry{Raw: raw, Time: mustParseRFC3339(raw)}); err != nil {
+					t.Fatalf("expected `nil` but got: %v", err)
+				}
+			}
+
+			var actual []string
+
+			for _, e := range ps.Entries() {
+				actual = append(actual, e.Raw)
+			}
+
+			sort.Strings(actual)
+
+			if _e, _a := strings.Join(tc.expected, ","), strings.Join(actual, ","); _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			}
+		})
+	}
+
+	for _, raw := range []string{"7d;at=00:00", "7d;by=day;at=mon 00:00", "7d;by=hour;at=00:30", "7d;by=week;at=someday 00:00"} {
+		if _, err := ParsePolicySpecString("policy-0", raw); err == nil {
+			t.Fatalf("expected error for %s but got: %v", raw, err)
+		}
+	}
+}
+
+func TestDurationBuckets(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw      string
+		time     string
+		expected string
+	}{
+		{raw: "7d;by=6h", time: "2022-12-31T17:59:59Z", expected: "2022-12-31T12:00:00Z"},
+		{raw: "7d;by=6h", time: "2022-12-31T18:00:00-05:00", expected: "2022-12-31T18:00:00Z"},
+		{raw: "7d;by=15m", time: "2022-12-31T10:44:00Z", expected: "2022-12-31T10:30:00Z"},
+		{raw: "1y;by=10d@2022-01-01T00:00:00Z", time: "2022-01-25T00:00:00Z", expected: "2022-01-21T00:00:00Z"},
+		{raw: "1y;by=10d@2022-01-01T00:00:00Z", time: "2021-12-31T00:00:00Z", expected: "2021-12-22T00:00:00Z"},
+	} {
+		t.Run(tc.raw+" "+tc.time, func(t *testing.T) {
+			spec, err := ParsePolicySpecString("policy-0", tc.raw)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			ts, err := parseRFC3339(tc.time)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			key, err := spec.bucket(&Entry{Time: ts})
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			} else if _e, _a := tc.expected, key; _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			}
+		})
+	}
+
+	if _, err := ParsePolicySpecString("policy-0", "7d;by=6h@yesterday"); err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
+
+func TestOffsetBuckets(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw      string
+		time     string
+		expected string
+	}{
+		{raw: "7d;by=day@04:00", time: "2022-12-31T00:30:00Z", expected: "2022-12-30"},
+		{raw: "7d;by=day@04:00", time: "2022-12-31T04:00:00Z", expected: "2022-12-31"},
+		{raw: "7d;by=hour@:30", time: "2022-12-31T10:15:00Z", expected: "2022-12-31T09"},
+		{raw: "4w;by=week@sun", time: "2022-12-24T12:00:00Z", expected: "2022-W50"},
+		{raw: "4w;by=week@sun", time: "2022-12-25T12:00:00Z", expected: "2022-W51"},
+		{raw: "1y;by=month@15", time: "2022-03-14T23:59:59Z", expected: "2022-02"},
+		{raw: "1y;by=month@15", time: "2022-03-15T00:00:00Z", expected: "2022-03"},
+		{raw: "2y;by=year@04-01", time: "2022-03-31T00:00:00Z", expected: "2021"},
+	} {
+		t.Run(tc.raw+" "+tc.time, func(t *testing.T) {
+			spec, err := ParsePolicySpecString("policy-0", tc.raw)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			key, err := spec.bucket(&Entry{Time: mustParseRFC3339(tc.time)})
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			} else if _e, _a := tc.expected, key; _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			}
+		})
+	}
+
+	spec, err := ParsePolicySpecString("policy-0", "3d;by=day@04:00")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	missing, err := FindMissingBuckets(spec, nil, time.UTC)
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "2022-12-28,2022-12-29,2022-12-30", strings.Join(missing, ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	for _, raw := range []string{"7d;by=day@4", "1y;by=month@31", "7d;by=week@someday", "7d;by=day@04:00;at=05:00"} {
+		if _, err := ParsePolicySpecString("policy-0", raw); err == nil {
+			t.Fatalf("expected error for %s but got: %v", raw, err)
+		}
+	}
+}
+
+func TestLast(t *testing.T) {
+	Now = stubNow
+
+	spec, err := ParsePolicySpecString("policy-0", "by=day;last=3")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	evictions := bytes.NewBuffer(nil)
+	ps := NewPolicySelection(spec, NewEntryWriter(evictions))
+
+	for _, raw := range []string{
+		"2022-10-01T12:00:00Z",
+		"2022-12-01T12:00:00Z",
+		"2022-11-01T12:00:00Z",
+		"2022-09-01T12:00:00Z",
+		"2022-12-01T18:00:00Z",
+		"2022-10-15T12:00:00Z",
+	} {
+		if _, err := ps.EvaluateEntry(&Entry{Raw: raw, Time: mustParseRFC3339(raw)}); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	var actual []string
+
+	for _, e := range ps.Entries() {
+		actual = append(actual, e.Raw)
+	}
+
+	sort.Strings(actual)
+
+	if _e, _a := "2022-10-15T12:00:00Z,2022-11-01T12:00:00Z,2022-12-01T18:00:00Z", strings.Join(actual, ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := "2022-12-01T12:00:00Z\n2022-10-01T12:00:00Z\n", evictions.String(); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	if _, err := ParsePolicySpecString("policy-0", "7d;last=3"); err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
+
+func TestFill(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw       string
+		entries   []string
+		expected  string
+		evictions string
+	}{
+		{
+			raw:       "by=day;last=3;fill",
+			entries:   []string{"2022-12-01T12:00:00Z", "2022-12-01T18:00:00Z", "2022-11-01T12:00:00Z", "2022-11-01T06:00:00Z"},
+			expected:  "2022-11-01T06:00:00Z,2022-11-01T12:00:00Z,2022-12-01T18:00:00Z",
+			evictions: "2022-12-01T12:00:00Z\n",
+		},
+		{
+			raw:       "by=day;last=3;fill",
+			entries:   []string{"2022-12-01T12:00:00Z", "2022-12-01T18:00:00Z", "2022-11-01T12:00:00Z", "2022-11-01T06:00:00Z", "2022-10-01T12:00:00Z"},
+			expected:  "2022-10-01T12:00:00Z,2022-11-01T12:00:00Z,2022-12-01T18:00:00Z",
+			evictions: "2022-12-01T12:00:00Z\n2022-11-01T06:00:00Z\n",
+		},
+		{
+			raw:       "7d;by=day;fill",
+			entries:   []string{"2022-12-31T18:00:00Z", "2022-12-31T06:00:00Z", "2022-12-30T12:00:00Z", "2022-12-30T06:00:00Z"},
+			expected:  "2022-12-30T06:00:00Z,2022-12-30T12:00:00Z,2022-12-31T18:00:00Z",
+			evictions: "2022-12-31T06:00:00Z\n",
+		},
+		{
+			raw:       "7d;by=day;fill",
+			entries:   []string{"2022-12-31T18:00:00Z", "2022-12-31T06:00:00Z", "2022-11-30T06:00:00Z"},
+			expected:  "2022-12-31T18:00:00Z",
+			evictions: "2022-12-31T06:00:00Z\n",
+		},
+	} {
+		spec, err := ParsePolicySpecString("policy-0", tc.raw)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		evictions := bytes.NewBuffer(nil)
+		ps := NewPolicySelection(spec.Resolve(Now()), NewEntryWriter(evictions))
+
+		for _, raw := range tc.entries {
+			if _, err := ps.EvaluateEntry(&Entry{Raw: raw, Time: mustParseRFC3339(raw)}); err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+		}
+
+		var actual []string
+
+		for _, e := range ps.Entries() {
+			actual = append(actual, e.Raw)
+		}
+
+		sort.Strings(actual)
+
+		if _e, _a := tc.expected, strings.Join(actual, ","); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		} else if _e, _a := tc.evictions, evictions.String(); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		} else if _e, _a := len(actual), ps.Stats().Selected; _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+
+	if _, err := ParsePolicySpecString("policy-0", "7d;fill"); err == nil {
+		t.Fatalf("expected error but got: %v", err)
+	}
+}
+
+func TestSetExclusive(t *testing.T) {
+	Now = stubNow
+
+	daily, err := ParsePolicySpecString("daily", "7d;by=day")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	weekly, err := ParsePolicySpecString("weekly", "4w;by=week")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	for _, tc := range []struct {
+		exclusive bool
+		expected  string
+	}{
+		{exclusive: false, expected: "2022-12-30T12:00:00Z=daily,2022-12-31T18:00:00Z=daily+weekly"},
+		{exclusive: true, expected: "2022-12-30T12:00:00Z=daily,2022-12-31T06:00:00Z=weekly,2022-12-31T18:00:00Z=daily"},
+	} {
+		pss := NewPolicySelectionSet([]*PolicySpec{daily, weekly}, NewDiscardEntryWriter())
+		pss.SetExclusive(tc.exclusive)
+
+		for _, raw := range []string{"2022-12-31T06:00:00Z", "2022-12-31T18:00:00Z", "2022-12-30T12:00:00Z"} {
+			if _, err := pss.EvaluateEntry(&Entry{Raw: raw, Time: mustParseRFC3339(raw)}); err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+		}
+
+		var actual []string
+
+		for _, e := range pss.Entries() {
+			var names []string
+
+			for _, claim := range pss.EntryClaims(e) {
+				names = append(names, claim.Spec.Name())
+			}
+
+			actual = append(actual, e.Raw+"="+strings.Join(names, "+"))
+		}
+
+		sort.Strings(actual)
+
+		if _e, _a := tc.expected, strings.Join(actual, ","); _e != _a {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+}
+
+func TestSetExclusiveEvictionError(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw     string
+		entries []string
+	}{
+		{raw: "by=day", entries: []string{"2022-12-31T06:00:00Z", "2022-12-31T18:00:00Z"}},
+		{raw: "by=day;edges", entries: []string{"2022-12-31T06:00:00Z", "2022-12-31T12:00:00Z", "2022-12-31T18:00:00Z"}},
+		{raw: "by=day;last=1", entries: []string{"2022-12-30T06:00:00Z", "2022-12-31T06:00:00Z"}},
+	} {
+		first, err := ParsePolicySpecString("first", tc.raw)
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		// fails for every entry, which is only offered once evicted by first
+		second, err := ParsePolicySpecString("second", "if=int(fields[1]) > 0")
+		if err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+
+		pss := NewPolicySelectionSet([]*PolicySpec{first, second}, NewDiscardEntryWriter())
+		pss.SetExclusive(true)
+
+		var lastErr error
+
+		for _, raw := range tc.entries {
+			_, lastErr = pss.EvaluateEntry(&Entry{Raw: raw, Time: mustParseRFC3339(raw), Fields: []string{raw}})
+		}
+
+		if lastErr == nil {
+			t.Fatalf("expected error for %s but got: %v", tc.raw, lastErr)
+		} else if _e, _a := "policy second", lastErr.Error(); !strings.Contains(_a, _e) {
+			t.Fatalf("expected `%v` but got: %v", _e, _a)
+		}
+	}
+}
+
+func TestEdges(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw      string
+		expected string
+	}{
+		{raw: "1y;by=month;edges", expected: "2022-12-01T00:00:00Z,2022-12-31T00:00:00Z"},
+		{raw: "1y;by=month;both;max=2", expected: "2022-12-01T00:00:00Z,2022-12-02T00:00:00Z,2022-12-30T00:00:00Z,2022-12-31T00:00:00Z"},
+	} {
+		t.Run(tc.raw, func(t *testing.T) {
+			spec, err := ParsePolicySpecString("policy-0", tc.raw)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			ps := NewPolicySelection(spec, NewDiscardEntryWriter())
+
+			for _, raw := range []string{
+				"2022-12-15T00:00:00Z",
+				"2022-12-31T00:00:00Z",
+				"2022-12-02T00:00:00Z",
+				"2022-12-16T00:00:00Z",
+				"2022-12-01T00:00:00Z",
+				"2022-12-30T00:00:00Z",
+			} {
+				if _, err := ps.EvaluateEntry(&Entry{Raw: raw, Time: mustParseRFC3339(raw)}); err != nil {
+					t.Fatalf("expected `nil` but got: %v", err)
+				}
+			}
+
+			var actual []string
+
+			for _, e := range ps.Entries() {
+				actual = append(actual, e.Raw)
+			}
+
+			if _e, _a := tc.expected, strings.Join(actual, ","); _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			}
+		})
+	}
+
+	for _, raw := range []string{"1y;by=month;edges;oldest", "1y;by=month;newest;both", "1y;by=month;edges;prefer=1"} {
+		if _, err := ParsePolicySpecString("policy-0", raw); err == nil {
+			t.Fatalf("expected error for %s but got: %v", raw, err)
+		}
+	}
+}
+
+func TestSpacing(t *testing.T) {
+	Now = stubNow
+
+	for _, tc := range []struct {
+		raw      string
+		expected string
+	}{
+		{raw: "30d;spacing=6h", expected: "2022-12-30T12:00:00Z,2022-12-31T00:01:00Z,2022-12-31T12:00:00Z"},
+		{raw: "30d;spacing=6h;oldest", expected: "2022-12-30T12:00:00Z,2022-12-30T23:59:00Z,2022-12-31T10:00:00Z"},
+		{raw: "30d;spacing=6h;max=2", expected: "2022-12-31T00:01:00Z,2022-12-31T12:00:00Z"},
+		{raw: "30d;by=day;spacing=12h", expected: "2022-12-30T23:59:00Z,2022-12-31T12:00:00Z"},
+	} {
+		t.Run(tc.raw, func(t *testing.T) {
+			spec, err := ParsePolicySpecString("policy-0", tc.raw)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			evictions := bytes.NewBuffer(nil)
+			pss := NewPolicySelectionSet([]*PolicySpec{spec}, NewEntryWriter(evictions))
+
+			for _, raw := range []string{
+				"2022-12-31T00:01:00Z",
+				"2022-12-30T12:00:00Z",
+				"2022-12-31T12:00:00Z",
+				"2022-12-30T23:59:00Z",
+				"2022-12-31T10:00:00Z",
+			} {
+				if _, err := pss.EvaluateEntry(&Entry{Raw: raw, Time: mustParseRFC3339(raw)}); err != nil {
+					t.Fatalf("expected `nil` but got: %v", err)
+				}
+			}
+
+			if err := pss.Flush(); err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			var actual []string
+
+			for _, e := range pss.Entries() {
+				actual = append(actual, e.Raw)
+			}
+
+			sort.Strings(actual)
+
+			if _e, _a := tc.expected, strings.Join(actual, ","); _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			} else if _e, _a := 5-len(actual), strings.Count(evictions.String(), "\n"); _e != _a {
+				t.Fatalf("expected `%v` but got: %v", _e, _a)
+			}
+		})
+	}
+}
+
+func TestDecay(t *testing.T) {
+	Now = stubNow
+
+	spec, err := ParsePolicySpecString("policy-0", "30d;decay=2")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	pss := NewPolicySelectionSet([]*PolicySpec{spec}, NewDiscardEntryWriter())
+
+	for hour := 0; hour < 72; hour++ {
+		ts := stubNow().Add(-time.Duration(hour)*time.Hour - 30*time.Minute)
+
+		if _, err := pss.EvaluateEntry(&Entry{Time: ts}); err != nil {
+			t.Fatalf("expected `nil` but got: %v", err)
+		}
+	}
+
+	if err := pss.Flush(); err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	}
+
+	var firstDay, nextDays int
+
+	for _, e := range pss.Entries() {
+		if stubNow().Sub(e.Time) < 24*time.Hour {
+			firstDay++
+		} else {
+			nextDays++
+		}
+	}
+
+	if _e, _a := 24, firstDay; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	} else if _e, _a := 24, nextDays; _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	for _, raw := range []string{"30d;decay=1", "30d;decay=two", "30d;decay=2;edges"} {
+		if _, err := ParsePolicySpecString("policy-0", raw); err == nil {
+			t.Fatalf("expected error for %s but got: %v", raw, err)
+		}
+	}
+}
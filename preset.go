@@ -0,0 +1,126 @@
+package timepolicy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var presets = map[string]func(args string) ([]string, error){
+	"exponential": presetFixed(
+		"decay=2;name=exponential // halve density each time age doubles",
+	),
+	"gfs": presetGFS,
+	"sanoid-backup": presetFixed(
+		"30h;by=hour;name=hourly // sanoid backup template: hourly=30",
+		"90d;by=day;name=daily // sanoid backup template: daily=90",
+		"12m;by=month;name=monthly // sanoid backup template: monthly=12",
+	),
+	"sanoid-production": presetFixed(
+		"36h;by=hour;name=hourly // sanoid production template: hourly=36",
+		"30d;by=day;name=daily // sanoid production template: daily=30",
+		"3m;by=month;name=monthly // sanoid production template: monthly=3",
+	),
+	"time-machine": presetFixed(
+		"24h;by=hour;name=hourly // hourly for the past 24 hours",
+		"1m;by=day;name=daily // daily for the past month",
+		"by=week;name=weekly // weekly for all previous months",
+	),
+}
+
+var presetGFSTiers = map[byte]struct {
+	bucket string
+	name   string
+}{
+	'h': {bucket: "hour", name: "hourly"},
+	'd': {bucket: "day", name: "daily"},
+	'w': {bucket: "week", name: "weekly"},
+	'm': {bucket: "month", name: "monthly"},
+	'y': {bucket: "year", name: "yearly"},
+}
+
+// PresetNames returns the names of the supported presets.
+func PresetNames() []string {
+	var names []string
+
+	for name := range presets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ExpandPreset returns the policy specifications of a preset in the format of
+// {NAME}[:{ARGS}], such as gfs:7d,4w,12m,5y.
+func ExpandPreset(value string) ([]string, error) {
+	valueSplit := strings.SplitN(value, ":", 2)
+
+	preset, ok := presets[valueSplit[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset: %s", valueSplit[0])
+	}
+
+	var args string
+
+	if len(valueSplit) == 2 {
+		args = valueSplit[1]
+	}
+
+	specs, err := preset(args)
+	if err != nil {
+		return nil, fmt.Errorf("preset %s: %v", valueSplit[0], err)
+	}
+
+	return specs, nil
+}
+
+func presetFixed(specs ...string) func(args string) ([]string, error) {
+	return func(args string) ([]string, error) {
+		if args != "" {
+			return nil, errors.New("arguments are not supported")
+		}
+
+		return specs, nil
+	}
+}
+
+// presetGFS expands grandfather-father-son tiers, such as 7d,4w,12m; each tier
+// keeps the newest entry per bucket of its unit within the count of its unit.
+func presetGFS(args string) ([]string, error) {
+	if args == "" {
+		args = "7d,4w,12m"
+	}
+
+	var specs []string
+	uniqTiers := map[byte]struct{}{}
+
+	for _, tier := range strings.Split(args, ",") {
+		if len(tier) < 2 {
+			return nil, fmt.Errorf("invalid tier: %q", tier)
+		}
+
+		unit := tier[len(tier)-1]
+
+		gfsTier, ok := presetGFSTiers[unit]
+		if !ok {
+			return nil, fmt.Errorf("invalid tier: %s: unit must be one of h, d, w, m, or y", tier)
+		} else if _, known := uniqTiers[unit]; known {
+			return nil, fmt.Errorf("invalid tier: %s: unit is already configured", tier)
+		}
+
+		uniqTiers[unit] = struct{}{}
+
+		count, err := strconv.Atoi(tier[0 : len(tier)-1])
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid tier: %s: count must be greater than 0", tier)
+		}
+
+		specs = append(specs, fmt.Sprintf("%d%c;by=%s;name=%s // keep newest by %s for %d %ss", count, unit, gfsTier.bucket, gfsTier.name, gfsTier.bucket, count, gfsTier.bucket))
+	}
+
+	return specs, nil
+}
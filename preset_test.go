@@ -0,0 +1,59 @@
+package timepolicy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandPreset(t *testing.T) {
+	specs, err := ExpandPreset("gfs:7d,4w,12m,5y")
+	if err != nil {
+		t.Fatalf("expected `nil` but got: %v", err)
+	} else if _e, _a := "7d;by=day;name=daily,4w;by=week;name=weekly,12m;by=month;name=monthly,5y;by=year;name=yearly", strings.Join(presetTestStripComments(specs), ","); _e != _a {
+		t.Fatalf("expected `%v` but got: %v", _e, _a)
+	}
+
+	for _, value := range []string{"gfs:7x", "gfs:7d,8d", "gfs:0d", "time-machine:1d", "unknown"} {
+		if _, err := ExpandPreset(value); err == nil {
+			t.Fatalf("expected error for %s but got: %v", value, err)
+		}
+	}
+}
+
+func TestPresetsLint(t *testing.T) {
+	Now = stubNow
+
+	for _, name := range PresetNames() {
+		t.Run(name, func(t *testing.T) {
+			raws, err := ExpandPreset(name)
+			if err != nil {
+				t.Fatalf("expected `nil` but got: %v", err)
+			}
+
+			var specs []*PolicySpec
+
+			for _, raw := range raws {
+				spec, err := ParsePolicySpecString("policy-0", raw)
+				if err != nil {
+					t.Fatalf("expected `nil` but got: %v", err)
+				}
+
+				specs = append(specs, spec)
+			}
+
+			if issues := LintPolicySpecs(specs); len(issues) > 0 {
+				t.Fatalf("expected no issues but got: %s: %s", issues[0].Policy, issues[0].Message)
+			}
+		})
+	}
+}
+
+func presetTestStripComments(specs []string) []string {
+	var stripped []string
+
+	for _, spec := range specs {
+		stripped = append(stripped, strings.SplitN(spec, " //", 2)[0])
+	}
+
+	return stripped
+}